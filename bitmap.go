@@ -0,0 +1,585 @@
+package ro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// maxBitmapOffset is the max bit offset of a string value of 512MB.
+	maxBitmapOffset = 1<<32 - 1
+
+	BitFieldWrap BitFieldOverflow = "WRAP"
+	BitFieldSat  BitFieldOverflow = "SAT"
+	BitFieldFail BitFieldOverflow = "FAIL"
+)
+
+var (
+	ErrBitOffsetOutOfRange = errors.New("bit offset out of range")
+
+	bitmapKeyPool = sync.Pool{
+		New: func() interface{} {
+			return &BitmapKey{}
+		},
+	}
+	bitmapParameterKeyPool = sync.Pool{
+		New: func() interface{} {
+			return &BitmapParameterKey{}
+		},
+	}
+)
+
+type BitmapKey struct {
+	*Key
+}
+
+func NewBitmapKey(key string) *BitmapKey {
+	k := bitmapKeyPool.Get().(*BitmapKey)
+	k.Key = NewKey(key)
+	return k
+}
+
+// SetBit returns the previous bit.
+func (k BitmapKey) SetBit(ctx context.Context, offset int64, value bool) (bool, error) {
+	start := time.Now()
+	previous, err := MustGetRedis(ctx).SetBit(ctx, k.key, offset, bitValue(value)).Result()
+	if err != nil {
+		log.Warn(ctx, "set bit failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int64("offset", offset),
+			log.Bool("value", value),
+			log.Duration("duration", time.Since(start)))
+		return false, err
+	}
+
+	log.Debug(ctx, "set bit successfully",
+		log.String("key", k.key),
+		log.Int64("offset", offset),
+		log.Bool("value", value),
+		log.Duration("duration", time.Since(start)))
+
+	return previous == 1, nil
+}
+
+// SetBits sets the bits of offsets in a pipeline.
+func (k BitmapKey) SetBits(ctx context.Context, offsets []int64, value bool) error {
+	if len(offsets) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	_, err := MustGetRedis(ctx).Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, offset := range offsets {
+			pipe.SetBit(ctx, k.key, offset, bitValue(value))
+		}
+		return nil
+	})
+	if err != nil {
+		log.Warn(ctx, "set bits failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int("count", len(offsets)),
+			log.Bool("value", value),
+			log.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	log.Debug(ctx, "set bits successfully",
+		log.String("key", k.key),
+		log.Int("count", len(offsets)),
+		log.Bool("value", value),
+		log.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+func (k BitmapKey) GetBit(ctx context.Context, offset int64) (bool, error) {
+	start := time.Now()
+	value, err := MustGetRedis(ctx).GetBit(ctx, k.key, offset).Result()
+	if err != nil {
+		log.Warn(ctx, "get bit failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int64("offset", offset),
+			log.Duration("duration", time.Since(start)))
+		return false, err
+	}
+
+	log.Debug(ctx, "get bit successfully",
+		log.String("key", k.key),
+		log.Int64("offset", offset),
+		log.Int64("value", value),
+		log.Duration("duration", time.Since(start)))
+
+	return value == 1, nil
+}
+
+// BitCount counts the set bits in the range of bitCount, or in the whole bitmap if it is nil.
+func (k BitmapKey) BitCount(ctx context.Context, bitCount *redis.BitCount) (int64, error) {
+	start := time.Now()
+	count, err := MustGetRedis(ctx).BitCount(ctx, k.key, bitCount).Result()
+	if err != nil {
+		log.Warn(ctx, "count bits failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Any("range", bitCount),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "count bits successfully",
+		log.String("key", k.key),
+		log.Any("range", bitCount),
+		log.Int64("count", count),
+		log.Duration("duration", time.Since(start)))
+
+	return count, nil
+}
+
+// BitPos returns the offset of the first bit equal to bit, positions are the optional start and end bytes.
+// It returns -1 if there is none.
+func (k BitmapKey) BitPos(ctx context.Context, bit bool, positions ...int64) (int64, error) {
+	start := time.Now()
+	offset, err := MustGetRedis(ctx).BitPos(ctx, k.key, int64(bitValue(bit)), positions...).Result()
+	if err != nil {
+		log.Warn(ctx, "get bit position failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Bool("bit", bit),
+			log.Any("positions", positions),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "get bit position successfully",
+		log.String("key", k.key),
+		log.Bool("bit", bit),
+		log.Any("positions", positions),
+		log.Int64("offset", offset),
+		log.Duration("duration", time.Since(start)))
+
+	return offset, nil
+}
+
+// Offsets returns the offsets of all set bits.
+func (k BitmapKey) Offsets(ctx context.Context) ([]int64, error) {
+	start := time.Now()
+	value, err := MustGetRedis(ctx).Get(ctx, k.key).Result()
+	if err != nil && err != redis.Nil {
+		log.Warn(ctx, "get bit offsets failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	offsets := bitOffsets(value)
+	log.Debug(ctx, "get bit offsets successfully",
+		log.String("key", k.key),
+		log.Int("count", len(offsets)),
+		log.Duration("duration", time.Since(start)))
+
+	return offsets, nil
+}
+
+// BitOpAnd stores the bitwise and into destination and returns its size in bytes, expiration 0 keeps destination persistent.
+func (k BitmapKey) BitOpAnd(ctx context.Context, destination *BitmapKey, expiration time.Duration, others ...*BitmapKey) (int64, error) {
+	return k.bitOp(ctx, "and", destination, expiration, others)
+}
+
+func (k BitmapKey) BitOpOr(ctx context.Context, destination *BitmapKey, expiration time.Duration, others ...*BitmapKey) (int64, error) {
+	return k.bitOp(ctx, "or", destination, expiration, others)
+}
+
+func (k BitmapKey) BitOpXor(ctx context.Context, destination *BitmapKey, expiration time.Duration, others ...*BitmapKey) (int64, error) {
+	return k.bitOp(ctx, "xor", destination, expiration, others)
+}
+
+func (k BitmapKey) BitOpNot(ctx context.Context, destination *BitmapKey, expiration time.Duration) (int64, error) {
+	return k.bitOp(ctx, "not", destination, expiration, nil)
+}
+
+func (k BitmapKey) bitOp(ctx context.Context, operation string, destination *BitmapKey, expiration time.Duration, others []*BitmapKey) (int64, error) {
+	start := time.Now()
+	keys := k.withOthers(others)
+
+	var cmd *redis.IntCmd
+	_, err := MustGetRedis(ctx).TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		cmd = bitOpCmd(ctx, pipe, operation, destination.key, keys)
+		if expiration > 0 {
+			pipe.PExpire(ctx, destination.key, expiration)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Warn(ctx, "bit "+operation+" failed",
+			log.Err(err),
+			log.Strings("keys", keys),
+			log.String("destination", destination.key),
+			log.Duration("expiration", expiration),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	size := cmd.Val()
+	log.Debug(ctx, "bit "+operation+" successfully",
+		log.Strings("keys", keys),
+		log.String("destination", destination.key),
+		log.Duration("expiration", expiration),
+		log.Int64("size", size),
+		log.Duration("duration", time.Since(start)))
+
+	return size, nil
+}
+
+func (k BitmapKey) withOthers(others []*BitmapKey) []string {
+	keys := make([]string, 0, len(others)+1)
+	keys = append(keys, k.key)
+	for _, other := range others {
+		keys = append(keys, other.key)
+	}
+
+	return keys
+}
+
+// BitField starts a BITFIELD command on k.
+func (k BitmapKey) BitField() *BitField {
+	return &BitField{key: k.key}
+}
+
+type BitmapParameterKey struct {
+	*ParameterKey
+}
+
+func NewBitmapParameterKey(pattern string) *BitmapParameterKey {
+	k := bitmapParameterKeyPool.Get().(*BitmapParameterKey)
+	k.ParameterKey = NewParameterKey(pattern)
+	return k
+}
+
+func (k BitmapParameterKey) Param(parameters ...interface{}) *BitmapKey {
+	return NewBitmapKey(fmt.Sprintf(k.pattern, parameters...))
+}
+
+// BitFieldType is a signed or unsigned integer of a width in bits.
+type BitFieldType string
+
+// BitFieldSigned supports width up to 64.
+func BitFieldSigned(width int) BitFieldType {
+	return BitFieldType("i" + strconv.Itoa(width))
+}
+
+// BitFieldUnsigned supports width up to 63.
+func BitFieldUnsigned(width int) BitFieldType {
+	return BitFieldType("u" + strconv.Itoa(width))
+}
+
+// BitFieldOverflow decides how the following SET and INCRBY sub commands overflow.
+type BitFieldOverflow string
+
+// BitField builds the sub commands of a BITFIELD command, offsets are in bits.
+type BitField struct {
+	key  string
+	args []interface{}
+}
+
+func (f *BitField) Get(fieldType BitFieldType, offset int64) *BitField {
+	f.args = append(f.args, "GET", string(fieldType), offset)
+	return f
+}
+
+// Set returns the previous value in the result.
+func (f *BitField) Set(fieldType BitFieldType, offset, value int64) *BitField {
+	f.args = append(f.args, "SET", string(fieldType), offset, value)
+	return f
+}
+
+// IncrBy returns the new value in the result.
+func (f *BitField) IncrBy(fieldType BitFieldType, offset, increment int64) *BitField {
+	f.args = append(f.args, "INCRBY", string(fieldType), offset, increment)
+	return f
+}
+
+func (f *BitField) Overflow(overflow BitFieldOverflow) *BitField {
+	f.args = append(f.args, "OVERFLOW", string(overflow))
+	return f
+}
+
+// Exec returns one result for each GET, SET and INCRBY, it is nil if the sub command failed with BitFieldFail.
+func (f *BitField) Exec(ctx context.Context) ([]*int64, error) {
+	start := time.Now()
+	args := append([]interface{}{"bitfield", f.key}, f.args...)
+	replies, err := MustGetRedis(ctx).Do(ctx, args...).Slice()
+	if err != nil {
+		log.Warn(ctx, "bitfield failed",
+			log.Err(err),
+			log.String("key", f.key),
+			log.Any("args", f.args),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	results := make([]*int64, len(replies))
+	for index, reply := range replies {
+		if reply == nil {
+			continue
+		}
+
+		value, ok := reply.(int64)
+		if !ok {
+			return nil, ErrInvalidEncodedValue
+		}
+
+		results[index] = &value
+	}
+
+	log.Debug(ctx, "bitfield successfully",
+		log.String("key", f.key),
+		log.Any("args", f.args),
+		log.Duration("duration", time.Since(start)))
+
+	return results, nil
+}
+
+// BitmapIDOffset maps ids from Base to bit offsets, a bitmap holds at most 2^32 bits.
+type BitmapIDOffset struct {
+	Base int64
+}
+
+func (o BitmapIDOffset) Offset(id int64) (int64, error) {
+	offset := id - o.Base
+	if offset < 0 || offset > maxBitmapOffset {
+		return 0, ErrBitOffsetOutOfRange
+	}
+
+	return offset, nil
+}
+
+func (o BitmapIDOffset) ID(offset int64) int64 {
+	return o.Base + offset
+}
+
+type DailyBitmapOption struct {
+	// Location decides the day boundaries, UTC if nil.
+	Location *time.Location
+	// Retention is how long a day is kept after it ends, forever if zero.
+	Retention time.Duration
+	IDOffset  BitmapIDOffset
+}
+
+// DailyBitmap keeps one bit per id per day, put a hash tag in key to keep all days in the same cluster slot.
+type DailyBitmap struct {
+	key    string
+	option DailyBitmapOption
+}
+
+func NewDailyBitmap(key string, option *DailyBitmapOption) *DailyBitmap {
+	d := &DailyBitmap{key: key}
+	if option != nil {
+		d.option = *option
+	}
+
+	if d.option.Location == nil {
+		d.option.Location = time.UTC
+	}
+
+	return d
+}
+
+// Day returns the bitmap of the day of t.
+func (d *DailyBitmap) Day(t time.Time) *BitmapKey {
+	return NewBitmapKey(d.key + ":" + t.In(d.option.Location).Format("20060102"))
+}
+
+// Mark sets the bits of ids on the day of t.
+func (d *DailyBitmap) Mark(ctx context.Context, t time.Time, ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	offsets := make([]int64, len(ids))
+	for index, id := range ids {
+		offset, err := d.option.IDOffset.Offset(id)
+		if err != nil {
+			return err
+		}
+
+		offsets[index] = offset
+	}
+
+	day := d.Day(t)
+	if d.option.Retention <= 0 {
+		return day.SetBits(ctx, offsets, true)
+	}
+
+	start := time.Now()
+	expireAt := CalendarDay.End(t.In(d.option.Location)).Add(d.option.Retention)
+	_, err := MustGetRedis(ctx).TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, offset := range offsets {
+			pipe.SetBit(ctx, day.key, offset, 1)
+		}
+		pipe.PExpireAt(ctx, day.key, expireAt)
+		return nil
+	})
+	if err != nil {
+		log.Warn(ctx, "mark daily bits failed",
+			log.Err(err),
+			log.String("key", day.key),
+			log.Any("ids", ids),
+			log.Time("expireAt", expireAt),
+			log.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	log.Debug(ctx, "mark daily bits successfully",
+		log.String("key", day.key),
+		log.Any("ids", ids),
+		log.Time("expireAt", expireAt),
+		log.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+// Active returns whether id is marked on the day of t.
+func (d *DailyBitmap) Active(ctx context.Context, t time.Time, id int64) (bool, error) {
+	offset, err := d.option.IDOffset.Offset(id)
+	if err != nil {
+		return false, err
+	}
+
+	return d.Day(t).GetBit(ctx, offset)
+}
+
+// Count returns how many ids are marked on the day of t.
+func (d *DailyBitmap) Count(ctx context.Context, t time.Time) (int64, error) {
+	return d.Day(t).BitCount(ctx, nil)
+}
+
+// CountActiveAll returns how many ids are marked on all of days.
+func (d *DailyBitmap) CountActiveAll(ctx context.Context, days ...time.Time) (int64, error) {
+	return d.countCombined(ctx, "and", days)
+}
+
+// CountActiveAny returns how many ids are marked on any of days.
+func (d *DailyBitmap) CountActiveAny(ctx context.Context, days ...time.Time) (int64, error) {
+	return d.countCombined(ctx, "or", days)
+}
+
+// ActiveAll returns the ids marked on all of days.
+func (d *DailyBitmap) ActiveAll(ctx context.Context, days ...time.Time) ([]int64, error) {
+	if len(days) == 0 {
+		return nil, nil
+	}
+
+	var cmd *redis.StringCmd
+	err := d.combine(ctx, "and", days, func(pipe redis.Pipeliner, temporary string) {
+		cmd = pipe.Get(ctx, temporary)
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	offsets := bitOffsets(cmd.Val())
+	ids := make([]int64, len(offsets))
+	for index, offset := range offsets {
+		ids[index] = d.option.IDOffset.ID(offset)
+	}
+
+	return ids, nil
+}
+
+func (d *DailyBitmap) countCombined(ctx context.Context, operation string, days []time.Time) (int64, error) {
+	if len(days) == 0 {
+		return 0, nil
+	}
+
+	var cmd *redis.IntCmd
+	err := d.combine(ctx, operation, days, func(pipe redis.Pipeliner, temporary string) {
+		cmd = pipe.BitCount(ctx, temporary, nil)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// combine stores the bitwise operation of days into a temporary key, reads it and deletes it in a transaction.
+func (d *DailyBitmap) combine(ctx context.Context, operation string, days []time.Time, read func(redis.Pipeliner, string)) error {
+	start := time.Now()
+	keys := make([]string, len(days))
+	for index, day := range days {
+		keys[index] = d.Day(day).key
+	}
+
+	temporary := temporaryKey(d.key)
+	_, err := MustGetRedis(ctx).TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		bitOpCmd(ctx, pipe, operation, temporary, keys)
+		read(pipe, temporary)
+		pipe.Del(ctx, temporary)
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		log.Warn(ctx, "combine daily bits failed",
+			log.Err(err),
+			log.String("operation", operation),
+			log.Strings("keys", keys),
+			log.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	log.Debug(ctx, "combine daily bits successfully",
+		log.String("operation", operation),
+		log.Strings("keys", keys),
+		log.Duration("duration", time.Since(start)))
+
+	return err
+}
+
+func bitOpCmd(ctx context.Context, pipe redis.Pipeliner, operation, destination string, keys []string) *redis.IntCmd {
+	switch operation {
+	case "and":
+		return pipe.BitOpAnd(ctx, destination, keys...)
+	case "or":
+		return pipe.BitOpOr(ctx, destination, keys...)
+	case "xor":
+		return pipe.BitOpXor(ctx, destination, keys...)
+	default:
+		return pipe.BitOpNot(ctx, destination, keys[0])
+	}
+}
+
+func bitValue(value bool) int {
+	if value {
+		return 1
+	}
+
+	return 0
+}
+
+// bitOffsets returns the offsets of the set bits of value, the highest bit of the first byte is offset 0.
+func bitOffsets(value string) []int64 {
+	var offsets []int64
+	for index := 0; index < len(value); index++ {
+		b := value[index]
+		if b == 0 {
+			continue
+		}
+
+		for bit := 0; bit < 8; bit++ {
+			if b&(0x80>>bit) != 0 {
+				offsets = append(offsets, int64(index*8+bit))
+			}
+		}
+	}
+
+	return offsets
+}
@@ -0,0 +1,187 @@
+package ro
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestBitmapKey(t *testing.T) {
+	ctx := context.Background()
+
+	k := NewBitmapParameterKey("test:bitmap:%s")
+	first, second, destination := k.Param("first"), k.Param("second"), k.Param("destination")
+	defer func() {
+		_ = first.Del(ctx)
+		_ = second.Del(ctx)
+		_ = destination.Del(ctx)
+	}()
+
+	previous, err := first.SetBit(ctx, 7, true)
+	if err != nil || previous {
+		t.Errorf("set bit failed, get %v, %v", previous, err)
+	}
+
+	previous, err = first.SetBit(ctx, 7, true)
+	if err != nil || !previous {
+		t.Errorf("set bit twice should return the previous bit, get %v, %v", previous, err)
+	}
+
+	err = first.SetBits(ctx, []int64{1, 9, 20}, true)
+	if err != nil {
+		t.Errorf("set bits failed, %v", err)
+	}
+
+	value, err := first.GetBit(ctx, 9)
+	if err != nil || !value {
+		t.Errorf("get bit failed, get %v, %v", value, err)
+	}
+
+	count, err := first.BitCount(ctx, nil)
+	if err != nil || count != 4 {
+		t.Errorf("bit count failed, get %d, %v", count, err)
+	}
+
+	count, err = first.BitCount(ctx, &redis.BitCount{Start: 1, End: 2})
+	if err != nil || count != 2 {
+		t.Errorf("bit count of range failed, get %d, %v", count, err)
+	}
+
+	offset, err := first.BitPos(ctx, true, 1)
+	if err != nil || offset != 9 {
+		t.Errorf("bit pos failed, get %d, %v", offset, err)
+	}
+
+	offsets, err := first.Offsets(ctx)
+	if err != nil || !reflect.DeepEqual(offsets, []int64{1, 7, 9, 20}) {
+		t.Errorf("offsets failed, get %v, %v", offsets, err)
+	}
+
+	_ = second.SetBits(ctx, []int64{7, 20, 30}, true)
+
+	_, err = first.BitOpAnd(ctx, destination, time.Minute, second)
+	offsets, _ = destination.Offsets(ctx)
+	if err != nil || !reflect.DeepEqual(offsets, []int64{7, 20}) {
+		t.Errorf("bit and failed, get %v, %v", offsets, err)
+	}
+
+	_, err = first.BitOpOr(ctx, destination, 0, second)
+	offsets, _ = destination.Offsets(ctx)
+	if err != nil || !reflect.DeepEqual(offsets, []int64{1, 7, 9, 20, 30}) {
+		t.Errorf("bit or failed, get %v, %v", offsets, err)
+	}
+
+	_, err = first.BitOpXor(ctx, destination, 0, second)
+	offsets, _ = destination.Offsets(ctx)
+	if err != nil || !reflect.DeepEqual(offsets, []int64{1, 9, 30}) {
+		t.Errorf("bit xor failed, get %v, %v", offsets, err)
+	}
+
+	size, err := first.BitOpNot(ctx, destination, 0)
+	count, _ = destination.BitCount(ctx, nil)
+	if err != nil || size != 3 || count != 20 {
+		t.Errorf("bit not failed, get %d, %d, %v", size, count, err)
+	}
+}
+
+func TestBitField(t *testing.T) {
+	ctx := context.Background()
+
+	k := NewBitmapKey("test:bitfield")
+	defer k.Del(ctx)
+
+	results, err := k.BitField().
+		Set(BitFieldUnsigned(8), 0, 200).
+		IncrBy(BitFieldUnsigned(8), 0, 100).
+		Overflow(BitFieldSat).
+		IncrBy(BitFieldUnsigned(8), 8, 300).
+		Overflow(BitFieldFail).
+		IncrBy(BitFieldSigned(4), 16, 8).
+		Get(BitFieldSigned(8), 0).
+		Exec(ctx)
+	if err != nil || len(results) != 5 {
+		t.Fatalf("bitfield failed, get %v, %v", results, err)
+	}
+
+	if *results[0] != 0 || *results[1] != 44 || *results[2] != 255 || results[3] != nil || *results[4] != 44 {
+		t.Errorf("bitfield results are wrong, get %d, %d, %d, %v, %d", *results[0], *results[1], *results[2], results[3], *results[4])
+	}
+}
+
+func TestBitmapIDOffset(t *testing.T) {
+	o := BitmapIDOffset{Base: 1000}
+
+	offset, err := o.Offset(1010)
+	if err != nil || offset != 10 || o.ID(offset) != 1010 {
+		t.Errorf("offset failed, get %d, %v", offset, err)
+	}
+
+	_, err = o.Offset(999)
+	if err != ErrBitOffsetOutOfRange {
+		t.Errorf("id below base should be out of range, get %v", err)
+	}
+
+	_, err = o.Offset(1000 + 1<<32)
+	if err != ErrBitOffsetOutOfRange {
+		t.Errorf("id beyond 2^32 bits should be out of range, get %v", err)
+	}
+}
+
+func TestDailyBitmap(t *testing.T) {
+	ctx := context.Background()
+
+	d := NewDailyBitmap("test:{active}", &DailyBitmapOption{
+		Retention: 24 * time.Hour,
+		IDOffset:  BitmapIDOffset{Base: 10000},
+	})
+	today := time.Now()
+	yesterday := today.AddDate(0, 0, -1)
+	defer func() {
+		_ = d.Day(today).Del(ctx)
+		_ = d.Day(yesterday).Del(ctx)
+	}()
+
+	_ = d.Mark(ctx, yesterday, 10001, 10002, 10003)
+	err := d.Mark(ctx, today, 10002, 10003, 10004)
+	if err != nil {
+		t.Fatalf("mark failed, %v", err)
+	}
+
+	err = d.Mark(ctx, today, 1)
+	if err != ErrBitOffsetOutOfRange {
+		t.Errorf("mark id below base should fail, get %v", err)
+	}
+
+	active, err := d.Active(ctx, today, 10004)
+	if err != nil || !active {
+		t.Errorf("active failed, get %v, %v", active, err)
+	}
+
+	count, err := d.Count(ctx, today)
+	if err != nil || count != 3 {
+		t.Errorf("count failed, get %d, %v", count, err)
+	}
+
+	count, err = d.CountActiveAll(ctx, yesterday, today)
+	if err != nil || count != 2 {
+		t.Errorf("count active all failed, get %d, %v", count, err)
+	}
+
+	count, err = d.CountActiveAny(ctx, yesterday, today)
+	if err != nil || count != 4 {
+		t.Errorf("count active any failed, get %d, %v", count, err)
+	}
+
+	ids, err := d.ActiveAll(ctx, yesterday, today)
+	if err != nil || !reflect.DeepEqual(ids, []int64{10002, 10003}) {
+		t.Errorf("active all failed, get %v, %v", ids, err)
+	}
+
+	ids, err = d.ActiveAll(ctx, today.AddDate(0, 0, -7), today)
+	if err != nil || len(ids) != 0 {
+		t.Errorf("active all with an empty day failed, get %v, %v", ids, err)
+	}
+}
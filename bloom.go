@@ -0,0 +1,543 @@
+package ro
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// BloomFilterKeyPattern keeps all keys of a bloom filter in the same cluster slot.
+	BloomFilterKeyPattern = "ro:bloom:{%s}"
+
+	defaultBloomFilterGrowth     = 2
+	defaultBloomFilterTightening = 0.5
+	maxBloomFilterRetries        = 3
+)
+
+var (
+	ErrBloomFilterBusy = errors.New("bloom filter is busy")
+
+	// bloomAddScript sets the bits of items and counts the new ones.
+	// KEYS are the bitmap and the counter, ARGV[1] is the hashes of each item, the rest are the offsets of all items.
+	// It returns 1 for each item with any bit newly set.
+	bloomAddScript = redis.NewScript(`
+local hashes = tonumber(ARGV[1])
+local result = {}
+local added = 0
+for index = 2, #ARGV, hashes do
+	local new = 0
+	for offset = index, index + hashes - 1 do
+		if redis.call('SETBIT', KEYS[1], ARGV[offset], 1) == 0 then
+			new = 1
+		end
+	end
+	result[#result + 1] = new
+	added = added + new
+end
+if added > 0 then
+	redis.call('INCRBY', KEYS[2], added)
+end
+return result
+`)
+
+	// bloomExistsScript KEYS are the bitmaps of the filters, ARGV[1] is the item count,
+	// ARGV[2..#KEYS+1] are the hashes of each filter, the rest are the offsets of each item in each filter.
+	// It returns 1 for each item found in any filter.
+	bloomExistsScript = redis.NewScript(`
+local items = tonumber(ARGV[1])
+local index = #KEYS + 2
+local result = {}
+for item = 1, items do
+	local found = 0
+	for filter = 1, #KEYS do
+		local hashes = tonumber(ARGV[filter + 1])
+		if found == 0 then
+			local all = 1
+			for offset = index, index + hashes - 1 do
+				if redis.call('GETBIT', KEYS[filter], ARGV[offset]) == 0 then
+					all = 0
+					break
+				end
+			end
+			found = all
+		end
+		index = index + hashes
+	end
+	result[item] = found
+end
+return result
+`)
+
+	// scalableBloomAddScript KEYS[1] is the filter counter, followed by the bitmap and the item counter of each filter,
+	// the last filter is the one to grow to. ARGV[1] is the filter count the offsets are derived for, ARGV[2] is the item count,
+	// followed by the hashes and the capacity of each filter, the rest are the offsets of each item in each filter.
+	// It returns -1 if the filter count is stale, or 1 for each item with any bit newly set.
+	scalableBloomAddScript = redis.NewScript(`
+local filters = tonumber(ARGV[1])
+if tonumber(redis.call('GET', KEYS[1]) or '1') ~= filters then
+	return -1
+end
+local items = tonumber(ARGV[2])
+-- the offsets are derived for one more filter than the original count, even after growing into it
+local derived = filters + 1
+local hashes, capacities = {}, {}
+for filter = 1, derived do
+	hashes[filter] = tonumber(ARGV[filter * 2 + 1])
+	capacities[filter] = tonumber(ARGV[filter * 2 + 2])
+end
+local index = derived * 2 + 3
+local result = {}
+for item = 1, items do
+	local starts, found = {}, false
+	for filter = 1, derived do
+		starts[filter] = index
+		index = index + hashes[filter]
+	end
+	for filter = 1, filters do
+		local all = true
+		for offset = starts[filter], starts[filter] + hashes[filter] - 1 do
+			if redis.call('GETBIT', KEYS[filter * 2], ARGV[offset]) == 0 then
+				all = false
+				break
+			end
+		end
+		if all then
+			found = true
+			break
+		end
+	end
+	if found then
+		result[item] = 0
+	else
+		if filters < derived and tonumber(redis.call('GET', KEYS[filters * 2 + 1]) or '0') >= capacities[filters] then
+			filters = filters + 1
+			redis.call('SET', KEYS[1], filters)
+		end
+		for offset = starts[filters], starts[filters] + hashes[filters] - 1 do
+			redis.call('SETBIT', KEYS[filters * 2], ARGV[offset], 1)
+		end
+		redis.call('INCR', KEYS[filters * 2 + 1])
+		result[item] = 1
+	end
+end
+return result
+`)
+)
+
+// BloomFilter answers whether an item may have been added, or has definitely not been added.
+type BloomFilter struct {
+	key      string
+	bits     *BitmapKey
+	count    *StringKey
+	size     uint64
+	hashes   int
+	capacity int64
+}
+
+// NewBloomFilter sizes the filter to keep the false positive rate for capacity items.
+func NewBloomFilter(name string, capacity int64, falsePositive float64) *BloomFilter {
+	return newBloomFilter(fmt.Sprintf(BloomFilterKeyPattern, name), capacity, falsePositive)
+}
+
+func newBloomFilter(key string, capacity int64, falsePositive float64) *BloomFilter {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	size, hashes := bloomFilterSize(capacity, falsePositive)
+	return &BloomFilter{
+		key:      key,
+		bits:     NewBitmapKey(key),
+		count:    NewStringKey(key + ":count"),
+		size:     size,
+		hashes:   hashes,
+		capacity: capacity,
+	}
+}
+
+// bloomFilterSize returns the bits m = -n*ln(p)/ln(2)^2 and the hashes k = m/n*ln(2).
+func bloomFilterSize(capacity int64, falsePositive float64) (uint64, int) {
+	if falsePositive <= 0 || falsePositive >= 1 {
+		falsePositive = 0.01
+	}
+
+	size := math.Ceil(-float64(capacity) * math.Log(falsePositive) / (math.Ln2 * math.Ln2))
+	size = math.Min(size, maxBitmapOffset+1)
+	hashes := int(math.Round(size / float64(capacity) * math.Ln2))
+	if hashes < 1 {
+		hashes = 1
+	}
+
+	return uint64(size), hashes
+}
+
+// Size returns the bits of the filter.
+func (f *BloomFilter) Size() uint64 {
+	return f.size
+}
+
+// Hashes returns the bits set for each item.
+func (f *BloomFilter) Hashes() int {
+	return f.hashes
+}
+
+// Add returns false if the item may have been added.
+func (f *BloomFilter) Add(ctx context.Context, item string) (bool, error) {
+	added, err := f.MAdd(ctx, item)
+	if err != nil {
+		return false, err
+	}
+
+	return added[0], nil
+}
+
+// MAdd adds items in one call, it returns false for each item which may have been added.
+func (f *BloomFilter) MAdd(ctx context.Context, items ...string) ([]bool, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	start := time.Now()
+	args := make([]interface{}, 1, 1+len(items)*f.hashes)
+	args[0] = f.hashes
+	for _, item := range items {
+		args = f.appendOffsets(args, item)
+	}
+
+	replies, err := bloomAddScript.Run(ctx, MustGetRedis(ctx), []string{f.bits.key, f.count.key}, args...).Int64Slice()
+	if err != nil {
+		log.Warn(ctx, "add bloom filter items failed",
+			log.Err(err),
+			log.String("key", f.key),
+			log.Strings("items", items),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, "add bloom filter items successfully",
+		log.String("key", f.key),
+		log.Strings("items", items),
+		log.Duration("duration", time.Since(start)))
+
+	return int64sToBools(replies), nil
+}
+
+// Exists returns false if the item has definitely not been added.
+func (f *BloomFilter) Exists(ctx context.Context, item string) (bool, error) {
+	exists, err := f.MExists(ctx, item)
+	if err != nil {
+		return false, err
+	}
+
+	return exists[0], nil
+}
+
+func (f *BloomFilter) MExists(ctx context.Context, items ...string) ([]bool, error) {
+	return bloomFiltersExist(ctx, f.key, []*BloomFilter{f}, items)
+}
+
+// Count returns how many items are added, items which are false positives when added are not counted.
+func (f *BloomFilter) Count(ctx context.Context) (int64, error) {
+	count, err := f.count.Get(ctx)
+	if err == redis.Nil {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(count, 10, 64)
+}
+
+func (f *BloomFilter) Del(ctx context.Context) error {
+	return deleteBloomFilter(ctx, f.key, []string{f.bits.key, f.count.key})
+}
+
+// appendOffsets appends the bits of item with the double hashing of Kirsch and Mitzenmacher, g(i) = h1 + i*h2.
+func (f *BloomFilter) appendOffsets(args []interface{}, item string) []interface{} {
+	h1, h2 := bloomHash(item)
+	for index := 0; index < f.hashes; index++ {
+		args = append(args, (h1+uint64(index)*h2)%f.size)
+	}
+
+	return args
+}
+
+func bloomHash(item string) (uint64, uint64) {
+	hash := fnv.New128a()
+	_, _ = hash.Write([]byte(item))
+	sum := hash.Sum(nil)
+
+	// an odd h2 never repeats the same bit before visiting the others
+	return binary.BigEndian.Uint64(sum[:8]), binary.BigEndian.Uint64(sum[8:]) | 1
+}
+
+func bloomFiltersExist(ctx context.Context, key string, filters []*BloomFilter, items []string) ([]bool, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	start := time.Now()
+	keys := make([]string, len(filters))
+	args := make([]interface{}, 1+len(filters))
+	args[0] = len(items)
+	for index, filter := range filters {
+		keys[index] = filter.bits.key
+		args[index+1] = filter.hashes
+	}
+
+	for _, item := range items {
+		for _, filter := range filters {
+			args = filter.appendOffsets(args, item)
+		}
+	}
+
+	replies, err := bloomExistsScript.Run(ctx, MustGetRedis(ctx), keys, args...).Int64Slice()
+	if err != nil {
+		log.Warn(ctx, "check bloom filter items failed",
+			log.Err(err),
+			log.String("key", key),
+			log.Strings("items", items),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, "check bloom filter items successfully",
+		log.String("key", key),
+		log.Strings("items", items),
+		log.Duration("duration", time.Since(start)))
+
+	return int64sToBools(replies), nil
+}
+
+func int64sToBools(values []int64) []bool {
+	bools := make([]bool, len(values))
+	for index, value := range values {
+		bools[index] = value == 1
+	}
+
+	return bools
+}
+
+type ScalableBloomFilterOption struct {
+	// Growth multiplies the capacity of each new filter, 2 if zero.
+	Growth float64
+	// Tightening multiplies the false positive rate of each new filter, 0.5 if zero.
+	Tightening float64
+}
+
+// ScalableBloomFilter chains bloom filters, a new filter is added when the last one reaches its capacity.
+// The overall false positive rate stays under falsePositive/(1-Tightening).
+type ScalableBloomFilter struct {
+	key           string
+	filters       *StringKey
+	capacity      int64
+	falsePositive float64
+	option        ScalableBloomFilterOption
+}
+
+func NewScalableBloomFilter(name string, capacity int64, falsePositive float64, option *ScalableBloomFilterOption) *ScalableBloomFilter {
+	key := fmt.Sprintf(BloomFilterKeyPattern, name)
+	f := &ScalableBloomFilter{
+		key:           key,
+		filters:       NewStringKey(key + ":filters"),
+		capacity:      capacity,
+		falsePositive: falsePositive,
+	}
+
+	if option != nil {
+		f.option = *option
+	}
+
+	if f.option.Growth < 1 {
+		f.option.Growth = defaultBloomFilterGrowth
+	}
+
+	if f.option.Tightening <= 0 || f.option.Tightening >= 1 {
+		f.option.Tightening = defaultBloomFilterTightening
+	}
+
+	return f
+}
+
+// Filter returns the filter of index.
+func (f *ScalableBloomFilter) Filter(index int) *BloomFilter {
+	capacity := float64(f.capacity) * math.Pow(f.option.Growth, float64(index))
+	falsePositive := f.falsePositive * math.Pow(f.option.Tightening, float64(index))
+	return newBloomFilter(fmt.Sprintf("%s:%d", f.key, index), int64(capacity), falsePositive)
+}
+
+// Filters returns how many filters are chained.
+func (f *ScalableBloomFilter) Filters(ctx context.Context) (int, error) {
+	filters, err := f.filters.Get(ctx)
+	if err == redis.Nil {
+		return 1, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(filters)
+}
+
+func (f *ScalableBloomFilter) Add(ctx context.Context, item string) (bool, error) {
+	added, err := f.MAdd(ctx, item)
+	if err != nil {
+		return false, err
+	}
+
+	return added[0], nil
+}
+
+// MAdd adds items which are not found in any filter to the last filter, it returns false for each item which may have been added.
+func (f *ScalableBloomFilter) MAdd(ctx context.Context, items ...string) ([]bool, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	for retry := 0; retry < maxBloomFilterRetries; retry++ {
+		count, err := f.Filters(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// the offsets of one more filter in case the last one is full
+		filters := make([]*BloomFilter, count+1)
+		keys := make([]string, 1, 1+len(filters)*2)
+		keys[0] = f.filters.key
+		args := make([]interface{}, 2, 2+len(filters)*2)
+		args[0], args[1] = count, len(items)
+		for index := range filters {
+			filters[index] = f.Filter(index)
+			keys = append(keys, filters[index].bits.key, filters[index].count.key)
+			args = append(args, filters[index].hashes, filters[index].capacity)
+		}
+
+		for _, item := range items {
+			for _, filter := range filters {
+				args = filter.appendOffsets(args, item)
+			}
+		}
+
+		start := time.Now()
+		reply, err := scalableBloomAddScript.Run(ctx, MustGetRedis(ctx), keys, args...).Result()
+		if err != nil {
+			log.Warn(ctx, "add scalable bloom filter items failed",
+				log.Err(err),
+				log.String("key", f.key),
+				log.Strings("items", items),
+				log.Duration("duration", time.Since(start)))
+			return nil, err
+		}
+
+		replies, ok := reply.([]interface{})
+		if !ok {
+			// another instance has added a filter
+			log.Debug(ctx, "scalable bloom filter count is stale",
+				log.String("key", f.key),
+				log.Int("filters", count))
+			continue
+		}
+
+		added := make([]bool, len(replies))
+		for index, value := range replies {
+			added[index] = value == int64(1)
+		}
+
+		log.Debug(ctx, "add scalable bloom filter items successfully",
+			log.String("key", f.key),
+			log.Strings("items", items),
+			log.Duration("duration", time.Since(start)))
+
+		return added, nil
+	}
+
+	return nil, ErrBloomFilterBusy
+}
+
+func (f *ScalableBloomFilter) Exists(ctx context.Context, item string) (bool, error) {
+	exists, err := f.MExists(ctx, item)
+	if err != nil {
+		return false, err
+	}
+
+	return exists[0], nil
+}
+
+func (f *ScalableBloomFilter) MExists(ctx context.Context, items ...string) ([]bool, error) {
+	count, err := f.Filters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := make([]*BloomFilter, count)
+	for index := range filters {
+		filters[index] = f.Filter(index)
+	}
+
+	return bloomFiltersExist(ctx, f.key, filters, items)
+}
+
+// Count returns how many items are added to all filters.
+func (f *ScalableBloomFilter) Count(ctx context.Context) (int64, error) {
+	count, err := f.Filters(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for index := 0; index < count; index++ {
+		added, err := f.Filter(index).Count(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		total += added
+	}
+
+	return total, nil
+}
+
+func (f *ScalableBloomFilter) Del(ctx context.Context) error {
+	count, err := f.Filters(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys := []string{f.filters.key}
+	for index := 0; index <= count; index++ {
+		filter := f.Filter(index)
+		keys = append(keys, filter.bits.key, filter.count.key)
+	}
+
+	return deleteBloomFilter(ctx, f.key, keys)
+}
+
+func deleteBloomFilter(ctx context.Context, key string, keys []string) error {
+	start := time.Now()
+	err := MustGetRedis(ctx).Del(ctx, keys...).Err()
+	if err != nil {
+		log.Warn(ctx, "delete bloom filter failed",
+			log.Err(err),
+			log.String("key", key),
+			log.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	log.Debug(ctx, "delete bloom filter successfully",
+		log.String("key", key),
+		log.Duration("duration", time.Since(start)))
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package ro
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestBloomFilterSize(t *testing.T) {
+	size, hashes := bloomFilterSize(1000, 0.01)
+	if size != 9586 || hashes != 7 {
+		t.Errorf("bloom filter size failed, get %d, %d", size, hashes)
+	}
+
+	size, hashes = bloomFilterSize(1<<40, 0.0001)
+	if size != maxBitmapOffset+1 || hashes != 1 {
+		t.Errorf("bloom filter size should be limited, get %d, %d", size, hashes)
+	}
+}
+
+func TestBloomFilter(t *testing.T) {
+	ctx := context.Background()
+
+	f := NewBloomFilter("test:usernames", 1000, 0.01)
+	defer f.Del(ctx)
+
+	added, err := f.Add(ctx, "alice")
+	if err != nil || !added {
+		t.Errorf("add failed, get %v, %v", added, err)
+	}
+
+	added, err = f.Add(ctx, "alice")
+	if err != nil || added {
+		t.Errorf("add twice should return false, get %v, %v", added, err)
+	}
+
+	addedItems, err := f.MAdd(ctx, "bob", "carol", "bob")
+	if err != nil || !reflect.DeepEqual(addedItems, []bool{true, true, false}) {
+		t.Errorf("madd failed, get %v, %v", addedItems, err)
+	}
+
+	exists, err := f.MExists(ctx, "alice", "bob", "dave")
+	if err != nil || !reflect.DeepEqual(exists, []bool{true, true, false}) {
+		t.Errorf("mexists failed, get %v, %v", exists, err)
+	}
+
+	count, err := f.Count(ctx)
+	if err != nil || count != 3 {
+		t.Errorf("count failed, get %d, %v", count, err)
+	}
+
+	items := make([]string, 1000)
+	for index := range items {
+		items[index] = fmt.Sprintf("user:%d", index)
+	}
+	_, _ = f.MAdd(ctx, items...)
+
+	falsePositives := 0
+	for index := 0; index < 1000; index++ {
+		exists, err := f.Exists(ctx, fmt.Sprintf("absent:%d", index))
+		if err != nil {
+			t.Fatalf("exists failed, %v", err)
+		}
+
+		if exists {
+			falsePositives++
+		}
+	}
+
+	if falsePositives > 30 {
+		t.Errorf("too many false positives, get %d", falsePositives)
+	}
+}
+
+func TestScalableBloomFilter(t *testing.T) {
+	ctx := context.Background()
+
+	f := NewScalableBloomFilter("test:scalable", 10, 0.01, nil)
+	defer f.Del(ctx)
+
+	items := make([]string, 50)
+	for index := range items {
+		items[index] = fmt.Sprintf("user:%d", index)
+	}
+
+	added, err := f.MAdd(ctx, items[:5]...)
+	if err != nil || len(added) != 5 || !added[0] {
+		t.Errorf("madd failed, get %v, %v", added, err)
+	}
+
+	for _, item := range items[5:] {
+		_, err = f.Add(ctx, item)
+		if err != nil {
+			t.Fatalf("add failed, %v", err)
+		}
+	}
+
+	filters, err := f.Filters(ctx)
+	if err != nil || filters != 3 {
+		t.Errorf("filters should grow to hold 10+20+40 items, get %d, %v", filters, err)
+	}
+
+	exists, err := f.MExists(ctx, items...)
+	if err != nil {
+		t.Fatalf("mexists failed, %v", err)
+	}
+
+	for index, found := range exists {
+		if !found {
+			t.Errorf("%s should exist", items[index])
+		}
+	}
+
+	addedAgain, err := f.Add(ctx, items[0])
+	if err != nil || addedAgain {
+		t.Errorf("add existed item should return false, get %v, %v", addedAgain, err)
+	}
+
+	count, err := f.Count(ctx)
+	if err != nil || count < 45 || count > 50 {
+		t.Errorf("count failed, get %d, %v", count, err)
+	}
+}
+
+func TestScalableBloomFilter_MAddGrows(t *testing.T) {
+	ctx := context.Background()
+
+	f := NewScalableBloomFilter("test:scalable:batch", 10, 0.01, nil)
+	defer f.Del(ctx)
+
+	items := make([]string, 30)
+	for index := range items {
+		items[index] = fmt.Sprintf("user:%d", index)
+	}
+
+	// the first filter is full in the middle of the batch
+	added, err := f.MAdd(ctx, items...)
+	if err != nil || len(added) != 30 {
+		t.Fatalf("madd across the capacity failed, get %v, %v", added, err)
+	}
+
+	filters, err := f.Filters(ctx)
+	if err != nil || filters != 2 {
+		t.Errorf("filters should grow to hold 10+20 items, get %d, %v", filters, err)
+	}
+
+	exists, err := f.MExists(ctx, items...)
+	if err != nil {
+		t.Fatalf("mexists failed, %v", err)
+	}
+
+	for index, found := range exists {
+		if !found {
+			t.Errorf("%s should exist", items[index])
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package ro
+
+import "time"
+
+type CalendarWindow int
+
+const (
+	CalendarHour CalendarWindow = iota + 1
+	CalendarDay
+	// CalendarWeek starts on Monday.
+	CalendarWeek
+	CalendarMonth
+)
+
+func (w CalendarWindow) Start(t time.Time) time.Time {
+	switch w {
+	case CalendarHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case CalendarDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	case CalendarWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+	case CalendarMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	default:
+		return t
+	}
+}
+
+func (w CalendarWindow) End(t time.Time) time.Time {
+	start := w.Start(t)
+	switch w {
+	case CalendarHour:
+		return time.Date(start.Year(), start.Month(), start.Day(), start.Hour()+1, 0, 0, 0, start.Location())
+	case CalendarDay:
+		return start.AddDate(0, 0, 1)
+	case CalendarWeek:
+		return start.AddDate(0, 0, 7)
+	case CalendarMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return t
+	}
+}
@@ -0,0 +1,58 @@
+package ro
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalendarWindow_StartAndEnd(t *testing.T) {
+	shanghai := time.FixedZone("Asia/Shanghai", 8*3600)
+	kolkata := time.FixedZone("Asia/Kolkata", 5*3600+1800)
+
+	tests := []struct {
+		name      string
+		window    CalendarWindow
+		t         time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			name:      "hour",
+			window:    CalendarHour,
+			t:         time.Date(2024, 3, 10, 13, 45, 0, 0, kolkata),
+			wantStart: time.Date(2024, 3, 10, 13, 0, 0, 0, kolkata),
+			wantEnd:   time.Date(2024, 3, 10, 14, 0, 0, 0, kolkata),
+		},
+		{
+			name:      "day",
+			window:    CalendarDay,
+			t:         time.Date(2024, 3, 10, 23, 59, 59, 0, shanghai),
+			wantStart: time.Date(2024, 3, 10, 0, 0, 0, 0, shanghai),
+			wantEnd:   time.Date(2024, 3, 11, 0, 0, 0, 0, shanghai),
+		},
+		{
+			name:      "week",
+			window:    CalendarWeek,
+			t:         time.Date(2024, 3, 10, 8, 0, 0, 0, shanghai),
+			wantStart: time.Date(2024, 3, 4, 0, 0, 0, 0, shanghai),
+			wantEnd:   time.Date(2024, 3, 11, 0, 0, 0, 0, shanghai),
+		},
+		{
+			name:      "month",
+			window:    CalendarMonth,
+			t:         time.Date(2024, 2, 29, 8, 0, 0, 0, shanghai),
+			wantStart: time.Date(2024, 2, 1, 0, 0, 0, 0, shanghai),
+			wantEnd:   time.Date(2024, 3, 1, 0, 0, 0, 0, shanghai),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.Start(tt.t); !got.Equal(tt.wantStart) {
+				t.Errorf("CalendarWindow.Start() = %v, want %v", got, tt.wantStart)
+			}
+			if got := tt.window.End(tt.t); !got.Equal(tt.wantEnd) {
+				t.Errorf("CalendarWindow.End() = %v, want %v", got, tt.wantEnd)
+			}
+		})
+	}
+}
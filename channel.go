@@ -0,0 +1,493 @@
+package ro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	channelKeyPool = sync.Pool{
+		New: func() interface{} {
+			return &ChannelKey{}
+		},
+	}
+	channelParameterKeyPool = sync.Pool{
+		New: func() interface{} {
+			return &ChannelParameterKey{}
+		},
+	}
+)
+
+// Backpressure decides what happens when a subscriber is slower than its publishers.
+type Backpressure int
+
+const (
+	// BackpressureBlock stops reading until the consumer catches up,
+	// redis may disconnect the subscriber once its output buffer limit is reached.
+	BackpressureBlock Backpressure = iota
+	// BackpressureDropNewest drops the incoming message when the buffer is full.
+	BackpressureDropNewest
+	// BackpressureDropOldest drops the oldest buffered message to make room for the incoming one.
+	BackpressureDropOldest
+)
+
+type SubscribeOption struct {
+	// BufferSize is the number of messages buffered for the consumer, 100 if zero.
+	BufferSize int
+	// Backpressure applies when the buffer is full, BackpressureBlock by default.
+	Backpressure Backpressure
+	// MinBackoff is the first wait before resubscribing after a connection loss, 100ms if zero.
+	MinBackoff time.Duration
+	// MaxBackoff caps the doubling wait between resubscribes, 30s if zero.
+	MaxBackoff time.Duration
+}
+
+type ChannelMessage[T any] struct {
+	Channel string
+	// Pattern is the matched pattern of a pattern subscription.
+	Pattern string
+	Payload T
+}
+
+// ChannelKey is a Pub/Sub channel, messages are delivered to the subscribers online at the moment only.
+// A channel is not a key in the keyspace, so it has no Del, Expire or TTL.
+type ChannelKey struct {
+	key string
+}
+
+func NewChannelKey(key string) *ChannelKey {
+	k := channelKeyPool.Get().(*ChannelKey)
+	k.key = key
+	return k
+}
+
+func (k ChannelKey) Key() string {
+	return k.key
+}
+
+// Publish returns the number of subscribers which received the message.
+func (k ChannelKey) Publish(ctx context.Context, message string) (int64, error) {
+	start := time.Now()
+	receivers, err := MustGetRedis(ctx).Publish(ctx, k.key, message).Result()
+	if err != nil {
+		log.Warn(ctx, "publish message failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("message", message),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "publish message successfully",
+		log.String("key", k.key),
+		log.String("message", message),
+		log.Int64("receivers", receivers),
+		log.Duration("duration", time.Since(start)))
+
+	return receivers, nil
+}
+
+// SPublish publishes to a shard channel, routed by slot if a cluster is configured by SetClusterConfig.
+func (k ChannelKey) SPublish(ctx context.Context, message string) (int64, error) {
+	client, err := shardClient(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	receivers, err := client.SPublish(ctx, k.key, message).Result()
+	if err != nil {
+		log.Warn(ctx, "publish shard message failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("message", message),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "publish shard message successfully",
+		log.String("key", k.key),
+		log.String("message", message),
+		log.Int64("receivers", receivers),
+		log.Duration("duration", time.Since(start)))
+
+	return receivers, nil
+}
+
+func (k ChannelKey) Subscribe(ctx context.Context, option *SubscribeOption) (*Subscription[ChannelMessage[string]], error) {
+	return subscribe(ctx, subscribeChannel, []string{k.key}, decodeChannelMessage, option)
+}
+
+// SSubscribe subscribes the shard channel published by SPublish, on the node serving its slot if a cluster is configured.
+func (k ChannelKey) SSubscribe(ctx context.Context, option *SubscribeOption) (*Subscription[ChannelMessage[string]], error) {
+	return subscribe(ctx, subscribeShardChannel, []string{k.key}, decodeChannelMessage, option)
+}
+
+// ChannelParameterKey is a pattern of channels, like ChannelKey it is not in the keyspace.
+type ChannelParameterKey struct {
+	pattern string
+}
+
+func NewChannelParameterKey(pattern string) *ChannelParameterKey {
+	k := channelParameterKeyPool.Get().(*ChannelParameterKey)
+	k.pattern = pattern
+	return k
+}
+
+func (k ChannelParameterKey) Param(parameters ...interface{}) *ChannelKey {
+	return NewChannelKey(fmt.Sprintf(k.pattern, parameters...))
+}
+
+// Match returns the glob style pattern matching all channels of the pattern.
+func (k ChannelParameterKey) Match() string {
+	return ParameterKey{pattern: k.pattern}.Match()
+}
+
+// PSubscribe subscribes all channels matching the pattern.
+func (k ChannelParameterKey) PSubscribe(ctx context.Context, option *SubscribeOption) (*Subscription[ChannelMessage[string]], error) {
+	return subscribe(ctx, subscribePattern, []string{k.Match()}, decodeChannelMessage, option)
+}
+
+type TypedChannelKey[T any] struct {
+	key   string
+	codec Codec[T]
+}
+
+func NewTypedChannelKey[T any](key string, codec Codec[T]) *TypedChannelKey[T] {
+	return &TypedChannelKey[T]{
+		key:   key,
+		codec: codec,
+	}
+}
+
+func (k TypedChannelKey[T]) Key() string {
+	return k.key
+}
+
+func (k TypedChannelKey[T]) Publish(ctx context.Context, message T) (int64, error) {
+	buffer, err := k.encode(ctx, message)
+	if err != nil {
+		return 0, err
+	}
+
+	return ChannelKey{key: k.key}.Publish(ctx, string(buffer))
+}
+
+func (k TypedChannelKey[T]) SPublish(ctx context.Context, message T) (int64, error) {
+	buffer, err := k.encode(ctx, message)
+	if err != nil {
+		return 0, err
+	}
+
+	return ChannelKey{key: k.key}.SPublish(ctx, string(buffer))
+}
+
+func (k TypedChannelKey[T]) Subscribe(ctx context.Context, option *SubscribeOption) (*Subscription[ChannelMessage[T]], error) {
+	return subscribe(ctx, subscribeChannel, []string{k.key}, typedChannelMessageDecoder(k.codec), option)
+}
+
+func (k TypedChannelKey[T]) SSubscribe(ctx context.Context, option *SubscribeOption) (*Subscription[ChannelMessage[T]], error) {
+	return subscribe(ctx, subscribeShardChannel, []string{k.key}, typedChannelMessageDecoder(k.codec), option)
+}
+
+func (k TypedChannelKey[T]) encode(ctx context.Context, message T) ([]byte, error) {
+	buffer, err := k.codec.Encode(message)
+	if err != nil {
+		log.Warn(ctx, "encode message failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Any("message", message))
+		return nil, err
+	}
+
+	return buffer, nil
+}
+
+type TypedChannelParameterKey[T any] struct {
+	pattern string
+	codec   Codec[T]
+}
+
+func NewTypedChannelParameterKey[T any](pattern string, codec Codec[T]) *TypedChannelParameterKey[T] {
+	return &TypedChannelParameterKey[T]{
+		pattern: pattern,
+		codec:   codec,
+	}
+}
+
+func (k TypedChannelParameterKey[T]) Param(parameters ...interface{}) *TypedChannelKey[T] {
+	return NewTypedChannelKey(fmt.Sprintf(k.pattern, parameters...), k.codec)
+}
+
+func (k TypedChannelParameterKey[T]) Match() string {
+	return ParameterKey{pattern: k.pattern}.Match()
+}
+
+func (k TypedChannelParameterKey[T]) PSubscribe(ctx context.Context, option *SubscribeOption) (*Subscription[ChannelMessage[T]], error) {
+	return subscribe(ctx, subscribePattern, []string{k.Match()}, typedChannelMessageDecoder(k.codec), option)
+}
+
+func decodeChannelMessage(message *redis.Message) (ChannelMessage[string], error) {
+	return ChannelMessage[string]{
+		Channel: message.Channel,
+		Pattern: message.Pattern,
+		Payload: message.Payload,
+	}, nil
+}
+
+func typedChannelMessageDecoder[T any](codec Codec[T]) func(*redis.Message) (ChannelMessage[T], error) {
+	return func(message *redis.Message) (ChannelMessage[T], error) {
+		payload, err := codec.Decode([]byte(message.Payload))
+		if err != nil {
+			return ChannelMessage[T]{}, err
+		}
+
+		return ChannelMessage[T]{
+			Channel: message.Channel,
+			Pattern: message.Pattern,
+			Payload: payload,
+		}, nil
+	}
+}
+
+// errSkipMessage is returned by a subscription decoder to filter out a message silently.
+var errSkipMessage = errors.New("skip message")
+
+type subscribeMode int
+
+const (
+	subscribeChannel subscribeMode = iota
+	subscribePattern
+	subscribeShardChannel
+)
+
+// Subscription receives messages in the background and resubscribes after connection loss until it is closed.
+type Subscription[T any] struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	pubsub   *redis.PubSub
+	channels []string
+	decode   func(*redis.Message) (T, error)
+	option   SubscribeOption
+	messages chan T
+	dropped  atomic.Int64
+	done     chan struct{}
+}
+
+// shardPubSub is implemented by both the single node client and the cluster client.
+type shardPubSub interface {
+	SPublish(ctx context.Context, channel string, message interface{}) *redis.IntCmd
+	SSubscribe(ctx context.Context, channels ...string) *redis.PubSub
+}
+
+// shardClient returns the cluster configured by SetClusterConfig, or the configured node.
+func shardClient(ctx context.Context) (shardPubSub, error) {
+	cluster, err := GetRedisCluster(ctx)
+	if err == ErrConfigUndefined {
+		return MustGetRedis(ctx), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return cluster, nil
+}
+
+func subscribe[T any](ctx context.Context, mode subscribeMode, channels []string, decode func(*redis.Message) (T, error), option *SubscribeOption) (*Subscription[T], error) {
+	var pubsub *redis.PubSub
+	if mode == subscribeShardChannel {
+		client, err := shardClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// the cluster client connects to the master of the channel slot, and looks it up again on reconnect
+		pubsub = client.SSubscribe(ctx)
+	} else {
+		pubsub = MustGetRedis(ctx).Subscribe(ctx)
+	}
+
+	s := &Subscription[T]{
+		pubsub:   pubsub,
+		channels: channels,
+		decode:   decode,
+		done:     make(chan struct{}),
+	}
+	if option != nil {
+		s.option = *option
+	}
+	if s.option.BufferSize <= 0 {
+		s.option.BufferSize = 100
+	}
+	if s.option.MinBackoff <= 0 {
+		s.option.MinBackoff = 100 * time.Millisecond
+	}
+	if s.option.MaxBackoff <= 0 {
+		s.option.MaxBackoff = 30 * time.Second
+	}
+	s.messages = make(chan T, s.option.BufferSize)
+
+	start := time.Now()
+	var err error
+	switch mode {
+	case subscribePattern:
+		err = s.pubsub.PSubscribe(ctx, channels...)
+	case subscribeShardChannel:
+		err = s.pubsub.SSubscribe(ctx, channels...)
+	default:
+		err = s.pubsub.Subscribe(ctx, channels...)
+	}
+	if err != nil {
+		log.Warn(ctx, "subscribe failed",
+			log.Err(err),
+			log.Strings("channels", channels),
+			log.Duration("duration", time.Since(start)))
+		_ = s.pubsub.Close()
+		return nil, err
+	}
+
+	log.Debug(ctx, "subscribe successfully",
+		log.Strings("channels", channels),
+		log.Duration("duration", time.Since(start)))
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	go s.receive()
+
+	return s, nil
+}
+
+// Channel returns the buffered messages, it is closed after the subscription is closed.
+func (s *Subscription[T]) Channel() <-chan T {
+	return s.messages
+}
+
+// Handle calls handler with every message until the subscription is closed, handler errors are logged only.
+func (s *Subscription[T]) Handle(handler func(ctx context.Context, message T) error) error {
+	for message := range s.messages {
+		err := s.call(handler, message)
+		if err != nil {
+			log.Warn(s.ctx, "handle message failed",
+				log.Err(err),
+				log.Strings("channels", s.channels),
+				log.Any("message", message))
+		}
+	}
+
+	return s.ctx.Err()
+}
+
+// Dropped returns the number of messages dropped by the backpressure policy.
+func (s *Subscription[T]) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close unsubscribes and waits for the messages channel to be closed.
+func (s *Subscription[T]) Close() error {
+	s.cancel()
+	<-s.done
+	return nil
+}
+
+func (s *Subscription[T]) receive() {
+	defer close(s.done)
+	defer close(s.messages)
+
+	// ReceiveMessage ignores the context cancellation, so closing pubsub unblocks it
+	go func() {
+		<-s.ctx.Done()
+		_ = s.pubsub.Close()
+	}()
+
+	backoff := s.option.MinBackoff
+	for {
+		// go-redis reconnects and resubscribes all channels on the next receive after a connection error
+		message, err := s.pubsub.ReceiveMessage(s.ctx)
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return
+			}
+
+			log.Warn(s.ctx, "receive message failed, resubscribe later",
+				log.Err(err),
+				log.Strings("channels", s.channels),
+				log.Duration("backoff", backoff))
+
+			if sleepContext(s.ctx, backoff) != nil {
+				return
+			}
+
+			backoff = min(backoff*2, s.option.MaxBackoff)
+			continue
+		}
+		backoff = s.option.MinBackoff
+
+		value, err := s.decode(message)
+		if err == errSkipMessage {
+			continue
+		}
+		if err != nil {
+			log.Warn(s.ctx, "decode message failed",
+				log.Err(err),
+				log.String("channel", message.Channel),
+				log.String("payload", message.Payload))
+			continue
+		}
+
+		s.deliver(message.Channel, value)
+	}
+}
+
+func (s *Subscription[T]) deliver(channel string, value T) {
+	switch s.option.Backpressure {
+	case BackpressureDropNewest:
+		select {
+		case s.messages <- value:
+		default:
+			s.drop(channel)
+		}
+	case BackpressureDropOldest:
+		for {
+			select {
+			case s.messages <- value:
+				return
+			default:
+			}
+
+			select {
+			case <-s.messages:
+				s.drop(channel)
+			default:
+			}
+		}
+	default:
+		select {
+		case s.messages <- value:
+		case <-s.ctx.Done():
+		}
+	}
+}
+
+func (s *Subscription[T]) drop(channel string) {
+	dropped := s.dropped.Add(1)
+	log.Warn(s.ctx, "consumer is too slow, drop message",
+		log.String("channel", channel),
+		log.Int64("dropped", dropped))
+}
+
+func (s *Subscription[T]) call(handler func(ctx context.Context, message T) error, message T) (err error) {
+	defer func() {
+		if err1 := recover(); err1 != nil {
+			log.Warn(s.ctx, "handler panic", log.Any("recover error", err1))
+			err = fmt.Errorf("handler panic: %+v", err1)
+		}
+	}()
+
+	return handler(s.ctx, message)
+}
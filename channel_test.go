@@ -0,0 +1,199 @@
+package ro
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// publishUntilReceived retries until the subscription is registered on the server.
+func publishUntilReceived(t *testing.T, publish func() (int64, error)) {
+	for index := 0; index < 50; index++ {
+		receivers, err := publish()
+		if err != nil {
+			t.Fatalf("publish failed, %v", err)
+		}
+
+		if receivers > 0 {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("publish should be received")
+}
+
+func receiveMessage[T any](t *testing.T, messages <-chan T) T {
+	select {
+	case message := <-messages:
+		return message
+	case <-time.After(time.Second):
+		t.Fatal("receive message timeout")
+	}
+
+	var message T
+	return message
+}
+
+func TestChannelKey(t *testing.T) {
+	ctx := context.Background()
+
+	k := NewChannelKey("test:channel")
+	s, err := k.Subscribe(ctx, nil)
+	if err != nil {
+		t.Fatalf("subscribe failed, %v", err)
+	}
+	defer s.Close()
+
+	publishUntilReceived(t, func() (int64, error) { return k.Publish(ctx, "hello") })
+
+	message := receiveMessage(t, s.Channel())
+	if message.Channel != "test:channel" || message.Payload != "hello" {
+		t.Errorf("receive message failed, get %+v", message)
+	}
+
+	_ = s.Close()
+	_, ok := <-s.Channel()
+	if ok {
+		t.Error("channel should be closed after the subscription is closed")
+	}
+}
+
+func TestChannelKey_SSubscribe(t *testing.T) {
+	ctx := context.Background()
+
+	k := NewChannelKey("test:{shard}:channel")
+	s, err := k.SSubscribe(ctx, nil)
+	if err != nil {
+		t.Fatalf("ssubscribe failed, %v", err)
+	}
+	defer s.Close()
+
+	publishUntilReceived(t, func() (int64, error) { return k.SPublish(ctx, "hello") })
+
+	message := receiveMessage(t, s.Channel())
+	if message.Payload != "hello" {
+		t.Errorf("receive shard message failed, get %+v", message)
+	}
+}
+
+func TestChannelKey_SSubscribe_Cluster(t *testing.T) {
+	ctx := context.Background()
+
+	withRedisCluster(t)
+
+	k := NewTypedChannelKey[string]("test:{shard}:cluster", StringCodec{})
+	s, err := k.SSubscribe(ctx, nil)
+	if err != nil {
+		t.Fatalf("ssubscribe on cluster failed, %v", err)
+	}
+	defer s.Close()
+
+	publishUntilReceived(t, func() (int64, error) { return k.SPublish(ctx, "hello") })
+
+	message := receiveMessage(t, s.Channel())
+	if message.Channel != "test:{shard}:cluster" || message.Payload != "hello" {
+		t.Errorf("receive shard message on cluster failed, get %+v", message)
+	}
+}
+
+func TestChannelParameterKey_PSubscribe(t *testing.T) {
+	ctx := context.Background()
+
+	k := NewChannelParameterKey("test:channel:%d:events")
+	s, err := k.PSubscribe(ctx, nil)
+	if err != nil {
+		t.Fatalf("psubscribe failed, %v", err)
+	}
+	defer s.Close()
+
+	publishUntilReceived(t, func() (int64, error) { return k.Param(42).Publish(ctx, "created") })
+
+	message := receiveMessage(t, s.Channel())
+	if message.Channel != "test:channel:42:events" || message.Pattern != "test:channel:*:events" || message.Payload != "created" {
+		t.Errorf("receive pattern message failed, get %+v", message)
+	}
+}
+
+func TestTypedChannelKey(t *testing.T) {
+	type invalidation struct {
+		Key     string
+		Version int
+	}
+
+	ctx := context.Background()
+
+	k := NewTypedChannelParameterKey[invalidation]("test:invalidation:%s", JSONCodec[invalidation]{})
+	s, err := k.PSubscribe(ctx, nil)
+	if err != nil {
+		t.Fatalf("psubscribe failed, %v", err)
+	}
+
+	received := make(chan ChannelMessage[invalidation], 1)
+	handled := make(chan error, 1)
+	go func() {
+		handled <- s.Handle(func(ctx context.Context, message ChannelMessage[invalidation]) error {
+			received <- message
+			return errors.New("handler errors are logged only")
+		})
+	}()
+
+	// invalid payloads are skipped
+	_, _ = NewChannelKey("test:invalidation:users").Publish(ctx, "{")
+	publishUntilReceived(t, func() (int64, error) {
+		return k.Param("users").Publish(ctx, invalidation{Key: "user:1", Version: 2})
+	})
+
+	message := receiveMessage(t, received)
+	if message.Channel != "test:invalidation:users" || message.Payload.Key != "user:1" || message.Payload.Version != 2 {
+		t.Errorf("receive typed message failed, get %+v", message)
+	}
+
+	_ = s.Close()
+	err = receiveMessage(t, handled)
+	if err != context.Canceled {
+		t.Errorf("handle should return after close, get %v", err)
+	}
+}
+
+func TestSubscription_Backpressure(t *testing.T) {
+	ctx := context.Background()
+
+	for _, c := range []struct {
+		backpressure Backpressure
+		payloads     []string
+	}{
+		{BackpressureDropNewest, []string{"0", "1"}},
+		{BackpressureDropOldest, []string{"3", "4"}},
+	} {
+		k := NewChannelKey("test:channel:backpressure")
+		s, err := k.Subscribe(ctx, &SubscribeOption{BufferSize: 2, Backpressure: c.backpressure})
+		if err != nil {
+			t.Fatalf("subscribe failed, %v", err)
+		}
+
+		publishUntilReceived(t, func() (int64, error) { return k.Publish(ctx, "0") })
+		for _, payload := range []string{"1", "2", "3", "4"} {
+			_, _ = k.Publish(ctx, payload)
+		}
+
+		for index := 0; index < 100 && s.Dropped() < 3; index++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if s.Dropped() != 3 {
+			t.Errorf("%d messages should be dropped, get %d", 3, s.Dropped())
+		}
+
+		for _, payload := range c.payloads {
+			message := receiveMessage(t, s.Channel())
+			if message.Payload != payload {
+				t.Errorf("backpressure %d should keep %s, get %s", c.backpressure, payload, message.Payload)
+			}
+		}
+
+		_ = s.Close()
+	}
+}
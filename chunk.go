@@ -0,0 +1,225 @@
+package ro
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	globalChunkOption = ChunkOption{
+		ChunkSize:    1000,
+		PipelineSize: 16,
+		Concurrency:  4,
+	}
+	globalChunkMutex sync.RWMutex
+)
+
+type ChunkOption struct {
+	// ChunkSize is the max members or fields sent in one command.
+	ChunkSize int
+	// PipelineSize is the max commands sent in one pipeline.
+	PipelineSize int
+	// Concurrency is the max pipelines in flight.
+	Concurrency int
+}
+
+// SetChunkOption changes how the bulk writes are split, zero values keep the current settings.
+func SetChunkOption(option ChunkOption) {
+	globalChunkMutex.Lock()
+	defer globalChunkMutex.Unlock()
+
+	if option.ChunkSize > 0 {
+		globalChunkOption.ChunkSize = option.ChunkSize
+	}
+
+	if option.PipelineSize > 0 {
+		globalChunkOption.PipelineSize = option.PipelineSize
+	}
+
+	if option.Concurrency > 0 {
+		globalChunkOption.Concurrency = option.Concurrency
+	}
+}
+
+func getChunkOption() ChunkOption {
+	globalChunkMutex.RLock()
+	defer globalChunkMutex.RUnlock()
+
+	return globalChunkOption
+}
+
+type ChunkFailure struct {
+	// Offset and Length locate the failed items in the input.
+	Offset int
+	Length int
+	// Items are the members or fields of the failed chunk, nil for stream messages.
+	Items []string
+	Err   error
+}
+
+// ChunkError reports the chunks that failed, the others have been applied.
+type ChunkError struct {
+	Total    int
+	Failures []ChunkFailure
+}
+
+func (e *ChunkError) Error() string {
+	var failed int
+	for _, failure := range e.Failures {
+		failed += failure.Length
+	}
+
+	return fmt.Sprintf("%d of %d items failed in %d chunks, first error: %v", failed, e.Total, len(e.Failures), e.Failures[0].Err)
+}
+
+func (e *ChunkError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for index, failure := range e.Failures {
+		errs[index] = failure.Err
+	}
+
+	return errs
+}
+
+// chunkCommand queues the command for items [from, to) and returns how to count the affected items.
+type chunkCommand func(ctx context.Context, pipe redis.Pipeliner, from, to int) (redis.Cmder, func() int64)
+
+// runChunks sends total items in commands of chunkSize items, pipelineSize commands per pipeline,
+// and at most concurrency pipelines in flight, concurrency 1 keeps the commands in the input order.
+func runChunks(ctx context.Context, total, chunkSize, pipelineSize, concurrency int, items func(from, to int) []string, command chunkCommand) (int64, error) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	type chunk struct {
+		from, to int
+	}
+
+	var groups [][]chunk
+	for from := 0; from < total; from += chunkSize * pipelineSize {
+		var group []chunk
+		for index := from; index < total && index < from+chunkSize*pipelineSize; index += chunkSize {
+			group = append(group, chunk{from: index, to: min(index+chunkSize, total)})
+		}
+		groups = append(groups, group)
+	}
+
+	var (
+		mutex    sync.Mutex
+		wg       sync.WaitGroup
+		affected int64
+		failures []ChunkFailure
+	)
+
+	fail := func(c chunk, err error) {
+		failure := ChunkFailure{Offset: c.from, Length: c.to - c.from, Err: err}
+		if items != nil {
+			failure.Items = items(c.from, c.to)
+		}
+		failures = append(failures, failure)
+	}
+
+	client := MustGetRedis(ctx)
+	semaphore := make(chan struct{}, concurrency)
+	for _, group := range groups {
+		var err error
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+
+		if err != nil {
+			mutex.Lock()
+			for _, c := range group {
+				fail(c, err)
+			}
+			mutex.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(group []chunk) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			cmds := make([]redis.Cmder, len(group))
+			counts := make([]func() int64, len(group))
+			_, _ = client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+				for index, c := range group {
+					cmds[index], counts[index] = command(ctx, pipe, c.from, c.to)
+				}
+				return nil
+			})
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			for index, c := range group {
+				err := cmds[index].Err()
+				if err != nil {
+					fail(c, err)
+					continue
+				}
+
+				affected += counts[index]()
+			}
+		}(group)
+	}
+	wg.Wait()
+
+	if len(failures) > 0 {
+		sort.Slice(failures, func(i, j int) bool { return failures[i].Offset < failures[j].Offset })
+		return affected, &ChunkError{Total: total, Failures: failures}
+	}
+
+	return affected, nil
+}
+
+func intCount(cmd *redis.IntCmd) (redis.Cmder, func() int64) {
+	return cmd, cmd.Val
+}
+
+// chunkedMembers runs a member based command over members in chunks.
+func chunkedMembers(ctx context.Context, operation, key string, members []string, fn func(ctx context.Context, pipe redis.Pipeliner, members []string) *redis.IntCmd) (int64, error) {
+	start := time.Now()
+	option := getChunkOption()
+	count, err := runChunks(ctx, len(members), option.ChunkSize, option.PipelineSize, option.Concurrency,
+		func(from, to int) []string {
+			return members[from:to]
+		},
+		func(ctx context.Context, pipe redis.Pipeliner, from, to int) (redis.Cmder, func() int64) {
+			return intCount(fn(ctx, pipe, members[from:to]))
+		})
+	if err != nil {
+		log.Warn(ctx, operation+" in chunks failed",
+			log.Err(err),
+			log.String("key", key),
+			log.Int("total", len(members)),
+			log.Int64("count", count),
+			log.Duration("duration", time.Since(start)))
+		return count, err
+	}
+
+	log.Debug(ctx, operation+" in chunks successfully",
+		log.String("key", key),
+		log.Int("total", len(members)),
+		log.Int64("count", count),
+		log.Duration("duration", time.Since(start)))
+
+	return count, nil
+}
+
+func stringsToInterfaces(values []string) []interface{} {
+	parameters := make([]interface{}, len(values))
+	for index, value := range values {
+		parameters[index] = value
+	}
+
+	return parameters
+}
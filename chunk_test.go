@@ -0,0 +1,142 @@
+package ro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func withChunkOption(t *testing.T, option ChunkOption) {
+	previous := getChunkOption()
+	SetChunkOption(option)
+	t.Cleanup(func() {
+		SetChunkOption(previous)
+	})
+}
+
+func TestSetKey_SAddInChunks(t *testing.T) {
+	ctx := context.Background()
+	withChunkOption(t, ChunkOption{ChunkSize: 100, PipelineSize: 3, Concurrency: 2})
+
+	key := NewSetKey("chunk:set")
+	defer key.Del(ctx)
+
+	members := make([]string, 2550)
+	for index := range members {
+		members[index] = fmt.Sprintf("member%d", index)
+	}
+
+	count, err := key.SAddCount(ctx, members...)
+	if err != nil || count != int64(len(members)) {
+		t.Errorf("sadd in chunks failed, get %d, %v", count, err)
+	}
+
+	total, err := key.SCard(ctx)
+	if err != nil || total != int64(len(members)) {
+		t.Errorf("scard failed, get %d, %v", total, err)
+	}
+
+	count, err = key.SRemCount(ctx, members[:1050]...)
+	if err != nil || count != 1050 {
+		t.Errorf("srem in chunks failed, get %d, %v", count, err)
+	}
+}
+
+func TestHashSetKey_InChunks(t *testing.T) {
+	ctx := context.Background()
+	withChunkOption(t, ChunkOption{ChunkSize: 100, PipelineSize: 2, Concurrency: 3})
+
+	key := NewHashSetKey("chunk:hash")
+	defer key.Del(ctx)
+
+	values := make(map[string]string, 1234)
+	fields := make([]string, 0, 1234)
+	for index := 0; index < 1234; index++ {
+		field := fmt.Sprintf("field%d", index)
+		values[field] = fmt.Sprint(index)
+		fields = append(fields, field)
+	}
+
+	count, err := key.HSetMap(ctx, values)
+	if err != nil || count != int64(len(values)) {
+		t.Errorf("hset in chunks failed, get %d, %v", count, err)
+	}
+
+	value, err := key.HGet(ctx, "field1000")
+	if err != nil || value != "1000" {
+		t.Errorf("hget failed, get %s, %v", value, err)
+	}
+
+	count, err = key.HDelCount(ctx, fields...)
+	if err != nil || count != int64(len(fields)) {
+		t.Errorf("hdel in chunks failed, get %d, %v", count, err)
+	}
+}
+
+func TestStreamKey_XAddBatch(t *testing.T) {
+	ctx := context.Background()
+	withChunkOption(t, ChunkOption{ChunkSize: 10, Concurrency: 2})
+
+	key := NewStreamKey("chunk:stream")
+	defer key.Del(ctx)
+
+	messages := make([]map[string]interface{}, 35)
+	for index := range messages {
+		messages[index] = map[string]interface{}{"index": index}
+	}
+
+	ids, err := key.XAddBatch(ctx, 0, 0, messages)
+	if err != nil || len(ids) != len(messages) {
+		t.Fatalf("xadd batch failed, get %v, %v", ids, err)
+	}
+
+	for _, id := range ids {
+		if id == "" {
+			t.Errorf("message id should not be empty")
+		}
+	}
+
+	entries, err := MustGetRedis(ctx).XRange(ctx, key.key, "-", "+").Result()
+	if err != nil || len(entries) != len(messages) {
+		t.Fatalf("xrange failed, get %d, %v", len(entries), err)
+	}
+
+	for index, entry := range entries {
+		if entry.ID != ids[index] || entry.Values["index"] != fmt.Sprint(index) {
+			t.Errorf("entries should keep the order of messages, get %s %v at %d", entry.ID, entry.Values, index)
+		}
+	}
+}
+
+func TestChunkError(t *testing.T) {
+	ctx := context.Background()
+	withChunkOption(t, ChunkOption{ChunkSize: 10, PipelineSize: 2, Concurrency: 2})
+
+	key := NewStringKey("chunk:wrongtype")
+	defer key.Del(ctx)
+
+	err := key.Set(ctx, "1", 0)
+	if err != nil {
+		t.Fatalf("set value failed due to %v", err)
+	}
+
+	members := make([]string, 25)
+	for index := range members {
+		members[index] = fmt.Sprint(index)
+	}
+
+	count, err := NewSetKey("chunk:wrongtype").SAddCount(ctx, members...)
+	var chunkErr *ChunkError
+	if !errors.As(err, &chunkErr) || count != 0 {
+		t.Fatalf("sadd to wrong type should return chunk error, get %d, %v", count, err)
+	}
+
+	if chunkErr.Total != 25 || len(chunkErr.Failures) != 3 {
+		t.Fatalf("chunk error should report 3 failed chunks, get %+v", chunkErr)
+	}
+
+	if chunkErr.Failures[2].Offset != 20 || len(chunkErr.Failures[2].Items) != 5 {
+		t.Errorf("chunk failure should locate the items, get %+v", chunkErr.Failures[2])
+	}
+}
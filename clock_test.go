@@ -0,0 +1,33 @@
+package ro
+
+import (
+	"sync"
+	"time"
+)
+
+// testClock is the Clock shared by the tests, it is read by the goroutines under test so it is only changed by Set and Add.
+type testClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+func (c *testClock) Set(now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = now
+}
+
+func (c *testClock) Add(duration time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(duration)
+}
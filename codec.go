@@ -0,0 +1,157 @@
+package ro
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+type Codec[T any] interface {
+	Encode(value T) ([]byte, error)
+	Decode(data []byte) (T, error)
+}
+
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+type Float interface {
+	~float32 | ~float64
+}
+
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Encode(value T) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (JSONCodec[T]) Decode(data []byte) (T, error) {
+	var value T
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
+
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) Encode(value T) ([]byte, error) {
+	buffer := new(bytes.Buffer)
+	err := gob.NewEncoder(buffer).Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (GobCodec[T]) Decode(data []byte) (T, error) {
+	var value T
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value)
+	return value, err
+}
+
+type BytesCodec struct{}
+
+func (BytesCodec) Encode(value []byte) ([]byte, error) {
+	return value, nil
+}
+
+func (BytesCodec) Decode(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+type StringCodec struct{}
+
+func (StringCodec) Encode(value string) ([]byte, error) {
+	return []byte(value), nil
+}
+
+func (StringCodec) Decode(data []byte) (string, error) {
+	return string(data), nil
+}
+
+// IntegerCodec stores integers as decimal strings, so they work with INCRBY and HINCRBY.
+type IntegerCodec[T Integer] struct{}
+
+func (IntegerCodec[T]) Encode(value T) ([]byte, error) {
+	if T(0)-1 < 0 {
+		return strconv.AppendInt(nil, int64(value), 10), nil
+	}
+
+	return strconv.AppendUint(nil, uint64(value), 10), nil
+}
+
+func (IntegerCodec[T]) Decode(data []byte) (T, error) {
+	if T(0)-1 < 0 {
+		parsed, err := strconv.ParseInt(string(data), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+
+		if int64(T(parsed)) != parsed {
+			return 0, &strconv.NumError{Func: "ParseInt", Num: string(data), Err: strconv.ErrRange}
+		}
+
+		return T(parsed), nil
+	}
+
+	parsed, err := strconv.ParseUint(string(data), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if uint64(T(parsed)) != parsed {
+		return 0, &strconv.NumError{Func: "ParseUint", Num: string(data), Err: strconv.ErrRange}
+	}
+
+	return T(parsed), nil
+}
+
+type FloatCodec[T Float] struct{}
+
+func (c FloatCodec[T]) Encode(value T) ([]byte, error) {
+	return strconv.AppendFloat(nil, float64(value), 'f', -1, c.bitSize()), nil
+}
+
+func (c FloatCodec[T]) Decode(data []byte) (T, error) {
+	parsed, err := strconv.ParseFloat(string(data), c.bitSize())
+	if err != nil {
+		return 0, err
+	}
+
+	return T(parsed), nil
+}
+
+func (FloatCodec[T]) bitSize() int {
+	if float64(T(0.1)) == float64(float32(0.1)) {
+		return 32
+	}
+
+	return 64
+}
+
+type TimeCodec struct{}
+
+func (TimeCodec) Encode(value time.Time) ([]byte, error) {
+	return value.AppendFormat(nil, time.RFC3339Nano), nil
+}
+
+func (TimeCodec) Decode(data []byte) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, string(data))
+}
+
+type DurationCodec struct{}
+
+func (DurationCodec) Encode(value time.Duration) ([]byte, error) {
+	return strconv.AppendInt(nil, int64(value), 10), nil
+}
+
+func (DurationCodec) Decode(data []byte) (time.Duration, error) {
+	parsed, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(parsed), nil
+}
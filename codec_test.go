@@ -0,0 +1,54 @@
+package ro
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testCodecRoundTrip[T any](t *testing.T, codec Codec[T], value T, wantEncoded string) {
+	t.Helper()
+
+	buffer, err := codec.Encode(value)
+	if err != nil {
+		t.Fatalf("encode %v failed due to %v", value, err)
+	}
+
+	if wantEncoded != "" && string(buffer) != wantEncoded {
+		t.Errorf("encode %v get %q, want %q", value, buffer, wantEncoded)
+	}
+
+	got, err := codec.Decode(buffer)
+	if err != nil {
+		t.Fatalf("decode %q failed due to %v", buffer, err)
+	}
+
+	if !reflect.DeepEqual(got, value) {
+		t.Errorf("decode %q get %v, want %v", buffer, got, value)
+	}
+}
+
+func TestCodec_RoundTrip(t *testing.T) {
+	testCodecRoundTrip(t, JSONCodec[*testStruct]{}, &testStruct{AAA: "a", BBB: 1}, `{"AAA":"a","BBB":1}`)
+	testCodecRoundTrip(t, GobCodec[testStruct]{}, testStruct{AAA: "a", BBB: 1}, "")
+	testCodecRoundTrip[[]byte](t, BytesCodec{}, []byte{0, 1, 2}, "\x00\x01\x02")
+	testCodecRoundTrip(t, StringCodec{}, "abc", "abc")
+	testCodecRoundTrip(t, IntegerCodec[int64]{}, -42, "-42")
+	testCodecRoundTrip(t, IntegerCodec[uint64]{}, 1<<63, "9223372036854775808")
+	testCodecRoundTrip(t, FloatCodec[float32]{}, 0.1, "0.1")
+	testCodecRoundTrip(t, FloatCodec[float64]{}, 0.25, "0.25")
+	testCodecRoundTrip(t, TimeCodec{}, time.Date(2024, 3, 10, 8, 0, 0, 1, time.UTC), "2024-03-10T08:00:00.000000001Z")
+	testCodecRoundTrip(t, DurationCodec{}, time.Second, "1000000000")
+}
+
+func TestIntegerCodec_DecodeOutOfRange(t *testing.T) {
+	_, err := IntegerCodec[int8]{}.Decode([]byte("128"))
+	if err == nil {
+		t.Errorf("decode out of range value should fail")
+	}
+
+	_, err = IntegerCodec[uint8]{}.Decode([]byte("-1"))
+	if err == nil {
+		t.Errorf("decode negative value into unsigned should fail")
+	}
+}
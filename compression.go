@@ -0,0 +1,145 @@
+package ro
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Encoded values written by the wrapping codecs start with valueHeader followed by a kind byte,
+// values without it are passed to the inner codec as is, so plain values stay readable.
+const (
+	valueHeader = "\x00ro"
+
+	valueKindGzip      byte = 'g'
+	valueKindZstd      byte = 'z'
+	valueKindEncrypted byte = 'e'
+)
+
+type Compression byte
+
+const (
+	CompressionGzip = Compression(valueKindGzip)
+	CompressionZstd = Compression(valueKindZstd)
+)
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+type CompressedCodec[T any] struct {
+	inner       Codec[T]
+	compression Compression
+	threshold   int
+}
+
+func NewCompressedCodec[T any](inner Codec[T], compression Compression, threshold int) *CompressedCodec[T] {
+	return &CompressedCodec[T]{
+		inner:       inner,
+		compression: compression,
+		threshold:   threshold,
+	}
+}
+
+func (c CompressedCodec[T]) Encode(value T) ([]byte, error) {
+	buffer, err := c.inner.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(buffer) < c.threshold {
+		return buffer, nil
+	}
+
+	header := append([]byte(valueHeader), byte(c.compression))
+	switch c.compression {
+	case CompressionGzip:
+		output := bytes.NewBuffer(header)
+		writer := gzip.NewWriter(output)
+		_, err = writer.Write(buffer)
+		if err != nil {
+			return nil, err
+		}
+
+		err = writer.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		return output.Bytes(), nil
+	case CompressionZstd:
+		encoder, _, err := getZstd()
+		if err != nil {
+			return nil, err
+		}
+
+		return encoder.EncodeAll(buffer, header), nil
+	default:
+		return nil, ErrUnknownValueKind
+	}
+}
+
+func (c CompressedCodec[T]) Decode(data []byte) (T, error) {
+	kind, payload, found := cutValueHeader(data)
+	if !found {
+		return c.inner.Decode(data)
+	}
+
+	var value T
+	var buffer []byte
+	var err error
+	switch kind {
+	case valueKindGzip:
+		var reader *gzip.Reader
+		reader, err = gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return value, err
+		}
+
+		buffer, err = io.ReadAll(reader)
+	case valueKindZstd:
+		var decoder *zstd.Decoder
+		_, decoder, err = getZstd()
+		if err != nil {
+			return value, err
+		}
+
+		buffer, err = decoder.DecodeAll(payload, nil)
+	default:
+		// the header belongs to an inner codec, like a value below the threshold
+		return c.inner.Decode(data)
+	}
+
+	if err != nil {
+		return value, err
+	}
+
+	return c.inner.Decode(buffer)
+}
+
+func cutValueHeader(data []byte) (byte, []byte, bool) {
+	if len(data) <= len(valueHeader) || !bytes.HasPrefix(data, []byte(valueHeader)) {
+		return 0, nil, false
+	}
+
+	return data[len(valueHeader)], data[len(valueHeader)+1:], true
+}
+
+func getZstd() (*zstd.Encoder, *zstd.Decoder, error) {
+	zstdOnce.Do(func() {
+		zstdEncoder, zstdErr = zstd.NewWriter(nil)
+		if zstdErr != nil {
+			return
+		}
+
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+
+	return zstdEncoder, zstdDecoder, zstdErr
+}
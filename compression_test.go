@@ -0,0 +1,102 @@
+package ro
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompressedCodec_RoundTrip(t *testing.T) {
+	value := strings.Repeat("abcdefgh", 128)
+
+	for _, compression := range []Compression{CompressionGzip, CompressionZstd} {
+		codec := NewCompressedCodec[string](StringCodec{}, compression, 64)
+
+		buffer, err := codec.Encode(value)
+		if err != nil {
+			t.Fatalf("compress value failed due to %v", err)
+		}
+
+		if !bytes.HasPrefix(buffer, []byte(valueHeader)) || len(buffer) >= len(value) {
+			t.Errorf("value should be compressed with header, get %d bytes", len(buffer))
+		}
+
+		got, err := codec.Decode(buffer)
+		if err != nil {
+			t.Fatalf("decompress value failed due to %v", err)
+		}
+
+		if got != value {
+			t.Errorf("decompressed value not equal")
+		}
+
+		small, err := codec.Encode("abc")
+		if err != nil {
+			t.Fatalf("encode small value failed due to %v", err)
+		}
+
+		if string(small) != "abc" {
+			t.Errorf("value below threshold should not be compressed, get %q", small)
+		}
+	}
+}
+
+func TestCompressedCodec_Encrypted(t *testing.T) {
+	keyring, err := NewKeyring("k1", map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, 32),
+	})
+	if err != nil {
+		t.Fatalf("create keyring failed due to %v", err)
+	}
+
+	codec := NewCompressedCodec[string](NewEncryptedCodec[string](StringCodec{}, keyring), CompressionZstd, 1024)
+
+	for _, value := range []string{"secret", strings.Repeat("secret", 1024)} {
+		buffer, err := codec.Encode(value)
+		if err != nil {
+			t.Fatalf("encode value failed due to %v", err)
+		}
+
+		got, err := codec.Decode(buffer)
+		if err != nil || got != value {
+			t.Errorf("decode %d bytes failed, get %d bytes, %v", len(value), len(got), err)
+		}
+	}
+}
+
+func TestCompressedCodec_StringKey(t *testing.T) {
+	ctx := context.Background()
+
+	plain := NewStringKey("compress:test")
+	defer plain.Del(ctx)
+
+	obj := &testStruct{AAA: strings.Repeat("a", 1024), BBB: 1}
+	err := plain.SetObject(ctx, obj, time.Minute)
+	if err != nil {
+		t.Errorf("set plain object failed due to %v", err)
+	}
+
+	key := NewTypedStringKey[*testStruct]("compress:test", NewCompressedCodec[*testStruct](JSONCodec[*testStruct]{}, CompressionZstd, 256))
+
+	get, err := key.Get(ctx)
+	if err != nil || get.AAA != obj.AAA {
+		t.Errorf("plain value should stay readable, get %v, %v", get, err)
+	}
+
+	err = key.Set(ctx, obj, time.Minute)
+	if err != nil {
+		t.Errorf("set compressed object failed due to %v", err)
+	}
+
+	raw, err := plain.Get(ctx)
+	if err != nil || len(raw) >= len(obj.AAA) {
+		t.Errorf("stored value should be compressed, get %d bytes, %v", len(raw), err)
+	}
+
+	get, err = key.Get(ctx)
+	if err != nil || get.AAA != obj.AAA {
+		t.Errorf("get compressed object failed, get %v, %v", get, err)
+	}
+}
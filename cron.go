@@ -0,0 +1,641 @@
+package ro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// CronKeyPattern keeps all keys of a cron job in the same cluster slot.
+	CronKeyPattern = "ro:cron:{%s}"
+
+	defaultCronGrace         = time.Minute
+	defaultCronTickRetention = 24 * time.Hour
+	defaultCronHistoryLen    = 1000
+	defaultCronMaxCatchUp    = 100
+
+	cronSearchLimit = 5 * 366 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidCronExpression = errors.New("invalid cron expression")
+
+	// cronAdvanceScript moves the last checked time forward and returns the previous one.
+	// KEYS[1] is the last key, ARGV[1] is the unix milliseconds now.
+	cronAdvanceScript = redis.NewScript(`
+local last = redis.call('GET', KEYS[1])
+if not last or tonumber(last) < tonumber(ARGV[1]) then
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+return last
+`)
+)
+
+// CronCatchUp decides which ticks run when the checks fell behind the schedule.
+type CronCatchUp int
+
+const (
+	// CronCatchUpNone runs the latest tick only if it is within the grace period.
+	CronCatchUpNone CronCatchUp = iota
+	// CronCatchUpLast runs the latest missed tick once.
+	CronCatchUpLast
+	// CronCatchUpAll runs every missed tick in order, at most MaxCatchUp of the latest ones.
+	CronCatchUpAll
+)
+
+// CronSchedule is a parsed cron expression with minute, hour, day of month, month and day of week fields.
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// restricted day fields match if either of them matches
+	domStar, dowStar bool
+}
+
+// cronEveryHour is the hour field matching every hour.
+const cronEveryHour = 1<<24 - 1
+
+type cronField struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	cronMinute = cronField{min: 0, max: 59}
+	cronHour   = cronField{min: 0, max: 23}
+	cronDom    = cronField{min: 1, max: 31}
+	cronMonth  = cronField{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is sunday as well
+	cronDow = cronField{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	cronDescriptors = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// ParseCron parses a standard 5 fields cron expression, or one of the @yearly, @monthly, @weekly, @daily and @hourly descriptors.
+func ParseCron(expression string) (*CronSchedule, error) {
+	expression = strings.TrimSpace(expression)
+	if descriptor, found := cronDescriptors[strings.ToLower(expression)]; found {
+		expression = descriptor
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: %q needs 5 fields", ErrInvalidCronExpression, expression)
+	}
+
+	s := &CronSchedule{
+		domStar: strings.HasPrefix(fields[2], "*"),
+		dowStar: strings.HasPrefix(fields[4], "*"),
+	}
+
+	var err error
+	for index, target := range []struct {
+		bits  *uint64
+		field cronField
+	}{
+		{&s.minute, cronMinute},
+		{&s.hour, cronHour},
+		{&s.dom, cronDom},
+		{&s.month, cronMonth},
+		{&s.dow, cronDow},
+	} {
+		*target.bits, err = target.field.parse(fields[index])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidCronExpression, expression, err)
+		}
+	}
+
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+
+	return s, nil
+}
+
+func (f cronField) parse(field string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+		}
+
+		low, high := f.min, f.max
+		if rangePart != "*" {
+			lowPart, highPart, isRange := strings.Cut(rangePart, "-")
+
+			var err error
+			low, err = f.value(lowPart)
+			if err != nil {
+				return 0, err
+			}
+
+			high = low
+			if isRange {
+				high, err = f.value(highPart)
+				if err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				high = f.max
+			}
+
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			bits |= 1 << value
+		}
+	}
+
+	return bits, nil
+}
+
+func (f cronField) value(text string) (int, error) {
+	if value, found := f.names[strings.ToLower(text)]; found {
+		return value, nil
+	}
+
+	value, err := strconv.Atoi(text)
+	if err != nil || value < f.min || value > f.max {
+		return 0, fmt.Errorf("invalid value %q", text)
+	}
+
+	return value, nil
+}
+
+// Next returns the first tick after t in the location of t, or a zero time if there is none in five years.
+func (s CronSchedule) Next(t time.Time) time.Time {
+	limit := t.Add(cronSearchLimit)
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = cronWallClock(t.Year(), t.Month()+1, 1, 0, t.Location())
+			continue
+		}
+
+		if !s.matchDay(t) {
+			t = cronWallClock(t.Year(), t.Month(), t.Day()+1, 0, t.Location())
+			continue
+		}
+
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			// Truncate works in absolute time, which is wrong for zones with a half-hour offset
+			t = cronWallClock(t.Year(), t.Month(), t.Day(), t.Hour()+1, t.Location())
+			continue
+		}
+
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		// the wall clock is repeated when the clocks fall back, a job at fixed hours runs at the first one only
+		if end := cronRepeatedUntil(t); s.hour != cronEveryHour && !end.IsZero() {
+			t = end
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// cronWallClock returns the start of the hour in loc, or the hour after if a daylight saving gap skips it,
+// because time.Date moves a skipped wall clock backwards.
+func cronWallClock(year int, month time.Month, day, hour int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, hour, 0, 0, 0, loc)
+	wall := time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	if t.Day() != wall.Day() || t.Hour() != wall.Hour() {
+		return t.Add(time.Hour)
+	}
+
+	return t
+}
+
+// cronRepeatedUntil returns the end of the repeated wall clocks if t is in them, or a zero time.
+func cronRepeatedUntil(t time.Time) time.Time {
+	start, _ := t.ZoneBounds()
+	if start.IsZero() {
+		return time.Time{}
+	}
+
+	_, offset := t.Zone()
+	_, previous := start.Add(-time.Second).Zone()
+	end := start.Add(time.Duration(previous-offset) * time.Second)
+	if t.Before(end) {
+		return end
+	}
+
+	return time.Time{}
+}
+
+func (s CronSchedule) matchDay(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
+
+type CronOption struct {
+	// Clock is the system clock if nil.
+	Clock Clock
+	// Location decides when the ticks are, UTC if nil.
+	Location *time.Location
+	CatchUp  CronCatchUp
+	// Grace is how late a tick can still run with CronCatchUpNone.
+	Grace time.Duration
+	// MaxCatchUp limits the missed ticks run with CronCatchUpAll.
+	MaxCatchUp int
+	// Timeout cancels the handler context, no timeout if zero.
+	Timeout time.Duration
+	// TickRetention is how long a claimed tick is remembered.
+	TickRetention time.Duration
+	// HistoryLen caps the run history stream.
+	HistoryLen int64
+	// Instance identifies this process in the run history, hostname with a random suffix if empty.
+	Instance string
+}
+
+// Cron runs a handler on every tick of a cron schedule, exactly one instance claims each tick.
+type Cron struct {
+	key      string
+	schedule *CronSchedule
+	handler  func(context.Context, time.Time) error
+	last     *StringKey
+	history  *StreamKey
+	option   CronOption
+}
+
+// CronRun is a record of the run history.
+type CronRun struct {
+	ID       string
+	Tick     time.Time
+	Instance string
+	Start    time.Time
+	Duration time.Duration
+	// Error is empty if the handler succeeded.
+	Error string
+}
+
+type CronStatus struct {
+	// LastChecked is the last time any instance checked the schedule, zero if never.
+	LastChecked time.Time
+	NextTick    time.Time
+	// LastRun is nil if the job never ran.
+	LastRun *CronRun
+}
+
+func NewCron(name, expression string, handler func(context.Context, time.Time) error, option *CronOption) (*Cron, error) {
+	schedule, err := ParseCron(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf(CronKeyPattern, name)
+	c := &Cron{
+		key:      key,
+		schedule: schedule,
+		handler:  handler,
+		last:     NewStringKey(key + ":last"),
+		history:  NewStreamKey(key + ":history"),
+	}
+
+	if option != nil {
+		c.option = *option
+	}
+
+	if c.option.Clock == nil {
+		c.option.Clock = systemClock{}
+	}
+
+	if c.option.Location == nil {
+		c.option.Location = time.UTC
+	}
+
+	if c.option.Grace <= 0 {
+		c.option.Grace = defaultCronGrace
+	}
+
+	if c.option.MaxCatchUp <= 0 {
+		c.option.MaxCatchUp = defaultCronMaxCatchUp
+	}
+
+	if c.option.TickRetention <= 0 {
+		c.option.TickRetention = defaultCronTickRetention
+	}
+
+	if c.option.HistoryLen <= 0 {
+		c.option.HistoryLen = defaultCronHistoryLen
+	}
+
+	if c.option.Instance == "" {
+		hostname, _ := os.Hostname()
+		c.option.Instance = hostname + "-" + randomID()
+	}
+
+	return c, nil
+}
+
+// History returns the run history stream, each message has tick, instance, start, duration and error fields.
+func (c *Cron) History() *StreamKey {
+	return c.history
+}
+
+// Check runs the ticks since the last check which this instance claims, it returns how many ticks are run.
+func (c *Cron) Check(ctx context.Context) (int, error) {
+	now := c.option.Clock.Now().In(c.option.Location)
+	last, err := c.advance(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	// the first check only starts the schedule
+	if last.IsZero() {
+		return 0, nil
+	}
+
+	count := 0
+	for _, tick := range c.ticks(last, now) {
+		claimed, err := c.claim(ctx, tick)
+		if err != nil {
+			return count, err
+		}
+
+		if !claimed {
+			continue
+		}
+
+		err = c.run(ctx, tick)
+		if err != nil {
+			return count, err
+		}
+
+		count++
+	}
+
+	return count, nil
+}
+
+// Run checks the schedule on every tick until ctx is done.
+func (c *Cron) Run(ctx context.Context) error {
+	for {
+		_, err := c.Check(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Warn(ctx, "check cron failed",
+				log.Err(err),
+				log.String("key", c.key))
+		}
+
+		now := c.option.Clock.Now().In(c.option.Location)
+		wait := defaultCronGrace
+		if next := c.schedule.Next(now); !next.IsZero() && next.Sub(now) < wait {
+			wait = next.Sub(now)
+		}
+
+		err = sleepContext(ctx, wait)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Status returns the last check, the next tick and the last run.
+func (c *Cron) Status(ctx context.Context) (*CronStatus, error) {
+	now := c.option.Clock.Now().In(c.option.Location)
+	status := &CronStatus{NextTick: c.schedule.Next(now)}
+
+	last, err := c.last.Get(ctx)
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	if err == nil {
+		milliseconds, err := strconv.ParseInt(last, 10, 64)
+		if err != nil {
+			return nil, ErrInvalidEncodedValue
+		}
+
+		status.LastChecked = time.UnixMilli(milliseconds).In(c.option.Location)
+	}
+
+	runs, err := c.Runs(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(runs) > 0 {
+		status.LastRun = runs[0]
+	}
+
+	return status, nil
+}
+
+// Runs returns the latest count runs, newest first.
+func (c *Cron) Runs(ctx context.Context, count int64) ([]*CronRun, error) {
+	start := time.Now()
+	messages, err := MustGetRedis(ctx).XRevRangeN(ctx, c.history.key, "+", "-", count).Result()
+	if err != nil {
+		log.Warn(ctx, "get cron runs failed",
+			log.Err(err),
+			log.String("key", c.history.key),
+			log.Int64("count", count),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, "get cron runs successfully",
+		log.String("key", c.history.key),
+		log.Int64("count", count),
+		log.Int("runs", len(messages)),
+		log.Duration("duration", time.Since(start)))
+
+	runs := make([]*CronRun, len(messages))
+	for index, message := range messages {
+		runs[index], err = c.decodeRun(message)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return runs, nil
+}
+
+func (c *Cron) advance(ctx context.Context, now time.Time) (time.Time, error) {
+	start := time.Now()
+	last, err := cronAdvanceScript.Run(ctx, MustGetRedis(ctx), []string{c.last.key}, now.UnixMilli()).Text()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+
+	if err != nil {
+		log.Warn(ctx, "advance cron failed",
+			log.Err(err),
+			log.String("key", c.last.key),
+			log.Time("now", now),
+			log.Duration("duration", time.Since(start)))
+		return time.Time{}, err
+	}
+
+	milliseconds, err := strconv.ParseInt(last, 10, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalidEncodedValue
+	}
+
+	return time.UnixMilli(milliseconds).In(c.option.Location), nil
+}
+
+// ticks returns the ticks in (last, now] which should run with the catch up policy.
+func (c *Cron) ticks(last, now time.Time) []time.Time {
+	limit := 1
+	if c.option.CatchUp == CronCatchUpAll {
+		limit = c.option.MaxCatchUp
+	}
+
+	var ticks []time.Time
+	for tick := c.schedule.Next(last); !tick.IsZero() && !tick.After(now); tick = c.schedule.Next(tick) {
+		ticks = append(ticks, tick)
+		if len(ticks) > limit {
+			ticks = ticks[1:]
+		}
+	}
+
+	if c.option.CatchUp == CronCatchUpNone && len(ticks) > 0 && now.Sub(ticks[0]) > c.option.Grace {
+		return nil
+	}
+
+	return ticks
+}
+
+func (c *Cron) claim(ctx context.Context, tick time.Time) (bool, error) {
+	key := fmt.Sprintf("%s:tick:%d", c.key, tick.Unix())
+	start := time.Now()
+	claimed, err := MustGetRedis(ctx).SetNX(ctx, key, c.option.Instance, c.option.TickRetention).Result()
+	if err != nil {
+		log.Warn(ctx, "claim cron tick failed",
+			log.Err(err),
+			log.String("key", key),
+			log.Duration("duration", time.Since(start)))
+		return false, err
+	}
+
+	log.Debug(ctx, "claim cron tick successfully",
+		log.String("key", key),
+		log.Bool("claimed", claimed),
+		log.Duration("duration", time.Since(start)))
+
+	return claimed, nil
+}
+
+func (c *Cron) run(ctx context.Context, tick time.Time) error {
+	handlerCtx, cancel := ctx, context.CancelFunc(func() {})
+	if c.option.Timeout > 0 {
+		handlerCtx, cancel = context.WithTimeout(ctx, c.option.Timeout)
+	}
+	defer cancel()
+
+	start := c.option.Clock.Now()
+	handlerStart := time.Now()
+	err := c.call(handlerCtx, tick)
+	duration := time.Since(handlerStart)
+
+	message := ""
+	if err != nil {
+		message = err.Error()
+		log.Warn(ctx, "cron handler failed",
+			log.Err(err),
+			log.String("key", c.key),
+			log.Time("tick", tick),
+			log.Duration("duration", duration))
+	}
+
+	_, err = c.history.XAdd(ctx, "", c.option.HistoryLen, 0, map[string]interface{}{
+		"tick":     tick.UnixMilli(),
+		"instance": c.option.Instance,
+		"start":    start.UnixMilli(),
+		"duration": duration.Milliseconds(),
+		"error":    message,
+	})
+
+	return err
+}
+
+func (c *Cron) call(ctx context.Context, tick time.Time) (err error) {
+	defer func() {
+		if err1 := recover(); err1 != nil {
+			log.Warn(ctx, "handler panic", log.Any("recover error", err1))
+			err = fmt.Errorf("handler panic: %+v", err1)
+		}
+	}()
+
+	return c.handler(ctx, tick)
+}
+
+func (c *Cron) decodeRun(message redis.XMessage) (*CronRun, error) {
+	run := &CronRun{ID: message.ID}
+	run.Instance, _ = message.Values["instance"].(string)
+	run.Error, _ = message.Values["error"].(string)
+
+	tick, err := parseMessageInt64(message, "tick")
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := parseMessageInt64(message, "start")
+	if err != nil {
+		return nil, err
+	}
+
+	duration, err := parseMessageInt64(message, "duration")
+	if err != nil {
+		return nil, err
+	}
+
+	run.Tick = time.UnixMilli(tick).In(c.option.Location)
+	run.Start = time.UnixMilli(start).In(c.option.Location)
+	run.Duration = time.Duration(duration) * time.Millisecond
+
+	return run, nil
+}
+
+func parseMessageInt64(message redis.XMessage, field string) (int64, error) {
+	value, _ := message.Values[field].(string)
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidEncodedValue
+	}
+
+	return number, nil
+}
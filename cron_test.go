@@ -0,0 +1,219 @@
+package ro
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func deleteCron(ctx context.Context, c *Cron) {
+	_, _ = DeleteByPattern(ctx, c.key+"*", nil)
+}
+
+func TestParseCron(t *testing.T) {
+	from := time.Date(2024, 1, 31, 10, 15, 30, 0, time.UTC)
+
+	tests := []struct {
+		expression string
+		want       time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 31, 10, 16, 0, 0, time.UTC)},
+		{"*/20 * * * *", time.Date(2024, 1, 31, 10, 20, 0, 0, time.UTC)},
+		{"5,10 11-12 * * *", time.Date(2024, 1, 31, 11, 5, 0, 0, time.UTC)},
+		{"0 0 * * sun", time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * fri", time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC)},
+		{"30 9 * * mon-fri", time.Date(2024, 2, 1, 9, 30, 0, 0, time.UTC)},
+		{"0 12/6 * * *", time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		schedule, err := ParseCron(tt.expression)
+		if err != nil {
+			t.Errorf("parse %q failed, %v", tt.expression, err)
+			continue
+		}
+
+		got := schedule.Next(from)
+		if !got.Equal(tt.want) {
+			t.Errorf("next of %q should be %v, get %v", tt.expression, tt.want, got)
+		}
+	}
+
+	kolkata := time.FixedZone("IST", 5*3600+1800)
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location failed, %v", err)
+	}
+
+	santiago, err := time.LoadLocation("America/Santiago")
+	if err != nil {
+		t.Fatalf("load location failed, %v", err)
+	}
+
+	zoned := []struct {
+		expression string
+		from       time.Time
+		want       time.Time
+	}{
+		{"0 11 * * *", time.Date(2024, 3, 10, 10, 45, 0, 0, kolkata), time.Date(2024, 3, 10, 11, 0, 0, 0, kolkata)},
+		{"30 * * * *", time.Date(2024, 3, 10, 10, 45, 0, 0, kolkata), time.Date(2024, 3, 10, 11, 30, 0, 0, kolkata)},
+		// 02:30 does not exist when the clocks spring forward
+		{"30 2 * * *", time.Date(2024, 3, 10, 0, 0, 0, 0, newYork), time.Date(2024, 3, 11, 2, 30, 0, 0, newYork)},
+		{"0 3 * * *", time.Date(2024, 3, 10, 0, 0, 0, 0, newYork), time.Date(2024, 3, 10, 3, 0, 0, 0, newYork)},
+		{"0 2 * * *", time.Date(2024, 11, 3, 0, 30, 0, 0, newYork), time.Date(2024, 11, 3, 2, 0, 0, 0, newYork)},
+		// 01:30 is repeated when the clocks fall back, a fixed hour runs at the first one only
+		{"30 1 * * *", time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC).In(newYork), time.Date(2026, 11, 2, 1, 30, 0, 0, newYork)},
+		{"30 * * * *", time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC).In(newYork), time.Date(2026, 11, 1, 6, 30, 0, 0, time.UTC)},
+		// midnight does not exist when the clocks spring forward in Santiago
+		{"0 * * * *", time.Date(2024, 9, 7, 23, 30, 0, 0, santiago), time.Date(2024, 9, 8, 1, 0, 0, 0, santiago)},
+		{"0 5 8 * *", time.Date(2024, 9, 7, 12, 0, 0, 0, santiago), time.Date(2024, 9, 8, 5, 0, 0, 0, santiago)},
+	}
+
+	for _, tt := range zoned {
+		schedule, err := ParseCron(tt.expression)
+		if err != nil {
+			t.Errorf("parse %q failed, %v", tt.expression, err)
+			continue
+		}
+
+		got := schedule.Next(tt.from)
+		if !got.Equal(tt.want) {
+			t.Errorf("next of %q from %v should be %v, get %v", tt.expression, tt.from, tt.want, got)
+		}
+	}
+
+	for _, expression := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		_, err := ParseCron(expression)
+		if !errors.Is(err, ErrInvalidCronExpression) {
+			t.Errorf("parse %q should fail, get %v", expression, err)
+		}
+	}
+}
+
+func TestCron_Check(t *testing.T) {
+	ctx := context.Background()
+
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 30, 0, time.UTC)}
+
+	var runs int32
+	var ticks []time.Time
+	var mutex sync.Mutex
+	handler := func(ctx context.Context, tick time.Time) error {
+		atomic.AddInt32(&runs, 1)
+		mutex.Lock()
+		ticks = append(ticks, tick)
+		mutex.Unlock()
+
+		if tick.Minute() == 1 {
+			return errors.New("first tick failed")
+		}
+
+		return nil
+	}
+
+	instances := make([]*Cron, 3)
+	for index := range instances {
+		c, err := NewCron("test:check", "* * * * *", handler, &CronOption{Clock: clock})
+		if err != nil {
+			t.Fatalf("new cron failed, %v", err)
+		}
+
+		instances[index] = c
+	}
+	defer deleteCron(ctx, instances[0])
+
+	// the first check starts the schedule
+	count, err := instances[0].Check(ctx)
+	if err != nil || count != 0 {
+		t.Errorf("first check failed, get %d, %v", count, err)
+	}
+
+	for minute := 1; minute <= 2; minute++ {
+		clock.Set(time.Date(2024, 1, 1, 0, minute, 1, 0, time.UTC))
+
+		var wg sync.WaitGroup
+		for _, c := range instances {
+			wg.Add(1)
+			go func(c *Cron) {
+				defer wg.Done()
+				_, err := c.Check(ctx)
+				if err != nil {
+					t.Errorf("check failed, %v", err)
+				}
+			}(c)
+		}
+		wg.Wait()
+	}
+
+	if runs != 2 || !ticks[0].Equal(time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC)) {
+		t.Errorf("every tick should run once, get %d runs, %v", runs, ticks)
+	}
+
+	status, err := instances[1].Status(ctx)
+	if err != nil {
+		t.Fatalf("status failed, %v", err)
+	}
+
+	if !status.NextTick.Equal(time.Date(2024, 1, 1, 0, 3, 0, 0, time.UTC)) ||
+		!status.LastChecked.Equal(clock.Now()) ||
+		status.LastRun == nil ||
+		!status.LastRun.Tick.Equal(time.Date(2024, 1, 1, 0, 2, 0, 0, time.UTC)) ||
+		status.LastRun.Error != "" {
+		t.Errorf("status failed, get %+v, %+v", status, status.LastRun)
+	}
+
+	history, err := instances[2].Runs(ctx, 10)
+	if err != nil || len(history) != 2 || history[1].Error != "first tick failed" || history[1].Instance == "" {
+		t.Errorf("runs failed, get %+v, %v", history, err)
+	}
+}
+
+func TestCron_CatchUp(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		catchUp CronCatchUp
+		want    []time.Time
+	}{
+		{CronCatchUpNone, nil},
+		{CronCatchUpLast, []time.Time{time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC)}},
+		{CronCatchUpAll, []time.Time{
+			time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC),
+		}},
+	}
+
+	for _, tt := range tests {
+		clock := &testClock{now: time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC)}
+
+		var ticks []time.Time
+		c, _ := NewCron("test:catchup", "@hourly", func(ctx context.Context, tick time.Time) error {
+			ticks = append(ticks, tick)
+			return nil
+		}, &CronOption{Clock: clock, CatchUp: tt.catchUp, MaxCatchUp: 2})
+
+		_, _ = c.Check(ctx)
+
+		// missed the ticks at 1:00, 2:00 and 3:00
+		clock.Set(time.Date(2024, 1, 1, 3, 20, 0, 0, time.UTC))
+		_, err := c.Check(ctx)
+		if err != nil || len(ticks) != len(tt.want) {
+			t.Errorf("catch up %d failed, get %v, %v", tt.catchUp, ticks, err)
+		}
+
+		for index := range ticks {
+			if index < len(tt.want) && !ticks[index].Equal(tt.want[index]) {
+				t.Errorf("catch up %d should run %v, get %v", tt.catchUp, tt.want, ticks)
+			}
+		}
+
+		deleteCron(ctx, c)
+	}
+}
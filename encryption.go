@@ -0,0 +1,120 @@
+package ro
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+)
+
+type Keyring struct {
+	current string
+	ciphers map[string]cipher.AEAD
+}
+
+// NewKeyring encrypts with the current key and decrypts with any of the keys,
+// so keys can be rotated by adding a new one and switching current to it.
+func NewKeyring(current string, keys map[string][]byte) (*Keyring, error) {
+	if _, found := keys[current]; !found {
+		return nil, ErrUnknownEncryptionKey
+	}
+
+	ciphers := make(map[string]cipher.AEAD, len(keys))
+	for id, key := range keys {
+		if len(id) == 0 || len(id) > 255 {
+			return nil, ErrBadRequest
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		ciphers[id] = aead
+	}
+
+	return &Keyring{
+		current: current,
+		ciphers: ciphers,
+	}, nil
+}
+
+type EncryptedCodec[T any] struct {
+	inner   Codec[T]
+	keyring *Keyring
+}
+
+func NewEncryptedCodec[T any](inner Codec[T], keyring *Keyring) *EncryptedCodec[T] {
+	return &EncryptedCodec[T]{
+		inner:   inner,
+		keyring: keyring,
+	}
+}
+
+func (c EncryptedCodec[T]) Encode(value T) ([]byte, error) {
+	buffer, err := c.inner.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	aead := c.keyring.ciphers[c.keyring.current]
+
+	// header, key id length, key id, nonce, sealed value
+	header := make([]byte, 0, len(valueHeader)+2+len(c.keyring.current))
+	header = append(header, valueHeader...)
+	header = append(header, valueKindEncrypted, byte(len(c.keyring.current)))
+	header = append(header, c.keyring.current...)
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]byte, 0, len(header)+len(nonce)+len(buffer)+aead.Overhead())
+	output = append(output, header...)
+	output = append(output, nonce...)
+	return aead.Seal(output, nonce, buffer, header), nil
+}
+
+func (c EncryptedCodec[T]) Decode(data []byte) (T, error) {
+	kind, payload, found := cutValueHeader(data)
+	if !found {
+		return c.inner.Decode(data)
+	}
+
+	// values written by an inner codec before encryption was enabled
+	if kind != valueKindEncrypted {
+		return c.inner.Decode(data)
+	}
+
+	var value T
+
+	if len(payload) < 1 || len(payload) < 1+int(payload[0]) {
+		return value, ErrInvalidEncodedValue
+	}
+
+	id := string(payload[1 : 1+payload[0]])
+	aead, found := c.keyring.ciphers[id]
+	if !found {
+		return value, ErrUnknownEncryptionKey
+	}
+
+	headerLength := len(valueHeader) + 2 + len(id)
+	if len(data) < headerLength+aead.NonceSize() {
+		return value, ErrInvalidEncodedValue
+	}
+
+	header := data[:headerLength]
+	nonce := data[headerLength : headerLength+aead.NonceSize()]
+	buffer, err := aead.Open(nil, nonce, data[headerLength+aead.NonceSize():], header)
+	if err != nil {
+		return value, err
+	}
+
+	return c.inner.Decode(buffer)
+}
@@ -0,0 +1,74 @@
+package ro
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncryptedCodec_RoundTrip(t *testing.T) {
+	oldKeyring, err := NewKeyring("k1", map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, 32),
+	})
+	if err != nil {
+		t.Fatalf("create keyring failed due to %v", err)
+	}
+
+	newKeyring, err := NewKeyring("k2", map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, 32),
+		"k2": bytes.Repeat([]byte{2}, 16),
+	})
+	if err != nil {
+		t.Fatalf("create keyring failed due to %v", err)
+	}
+
+	oldCodec := NewEncryptedCodec[string](StringCodec{}, oldKeyring)
+	newCodec := NewEncryptedCodec[string](NewCompressedCodec[string](StringCodec{}, CompressionGzip, 0), newKeyring)
+
+	encrypted, err := oldCodec.Encode("secret")
+	if err != nil {
+		t.Fatalf("encrypt value failed due to %v", err)
+	}
+
+	if bytes.Contains(encrypted, []byte("secret")) {
+		t.Errorf("encrypted value should not contain plain text")
+	}
+
+	compressed, err := NewCompressedCodec[string](StringCodec{}, CompressionGzip, 0).Encode("secret")
+	if err != nil {
+		t.Fatalf("compress value failed due to %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr bool
+	}{
+		{name: "plain", data: []byte("secret"), want: "secret"},
+		{name: "compressed only", data: compressed, want: "secret"},
+		{name: "rotated key", data: encrypted, want: "secret"},
+		{name: "tampered", data: append(bytes.Clone(encrypted[:len(encrypted)-1]), encrypted[len(encrypted)-1]^1), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newCodec.Decode(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("EncryptedCodec.Decode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("EncryptedCodec.Decode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	encrypted, err = newCodec.Encode("secret")
+	if err != nil {
+		t.Fatalf("encrypt value failed due to %v", err)
+	}
+
+	_, err = oldCodec.Decode(encrypted)
+	if err != ErrUnknownEncryptionKey {
+		t.Errorf("decrypt with unknown key should fail, get %v", err)
+	}
+}
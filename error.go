@@ -3,8 +3,12 @@ package ro
 import "errors"
 
 var (
-	ErrBadRequest         = errors.New("bad request")
-	ErrInvalidResultCount = errors.New("invalid result count")
-	ErrConfigUndefined    = errors.New("redis config undefined")
-	ErrRecordNotFound     = errors.New("record not found")
+	ErrBadRequest               = errors.New("bad request")
+	ErrInvalidResultCount       = errors.New("invalid result count")
+	ErrConfigUndefined          = errors.New("redis config undefined")
+	ErrRecordNotFound           = errors.New("record not found")
+	ErrUnknownValueKind         = errors.New("unknown encoded value kind")
+	ErrInvalidEncodedValue      = errors.New("invalid encoded value")
+	ErrUnknownEncryptionKey     = errors.New("unknown encryption key")
+	ErrUnsupportedSchemaVersion = errors.New("unsupported schema version")
 )
@@ -0,0 +1,527 @@
+package ro
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	GeoMeters     GeoUnit = "m"
+	GeoKilometers GeoUnit = "km"
+	GeoMiles      GeoUnit = "mi"
+	GeoFeet       GeoUnit = "ft"
+
+	GeoAsc  GeoSort = "ASC"
+	GeoDesc GeoSort = "DESC"
+
+	defaultExpiringGeoBatchSize = 100
+)
+
+var (
+	geoKeyPool = sync.Pool{
+		New: func() interface{} {
+			return &GeoKey{}
+		},
+	}
+	geoParameterKeyPool = sync.Pool{
+		New: func() interface{} {
+			return &GeoParameterKey{}
+		},
+	}
+
+	// geoExpireScript removes the members not updated since a time from the positions and the update times.
+	// KEYS are the positions and the update times, ARGV[1] is the unix milliseconds, ARGV[2] is the max members to remove.
+	geoExpireScript = redis.NewScript(`
+local stale = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', '(' .. ARGV[1], 'LIMIT', 0, tonumber(ARGV[2]))
+if #stale > 0 then
+	redis.call('ZREM', KEYS[1], unpack(stale))
+	redis.call('ZREM', KEYS[2], unpack(stale))
+end
+return stale
+`)
+)
+
+type GeoUnit string
+
+type GeoSort string
+
+type GeoPosition struct {
+	Longitude float64
+	Latitude  float64
+}
+
+type GeoLocation struct {
+	Member    string
+	Longitude float64
+	Latitude  float64
+}
+
+type GeoAddArgs struct {
+	// NX only adds new members, XX only updates existing members.
+	NX bool
+	XX bool
+	// CH counts the updated members as well as the added ones.
+	CH bool
+}
+
+// GeoSearchQuery searches around Member, or around Longitude and Latitude if Member is empty,
+// within Radius, or within the box of Width and Height if Radius is 0.
+type GeoSearchQuery struct {
+	Member    string
+	Longitude float64
+	Latitude  float64
+	Radius    float64
+	Width     float64
+	Height    float64
+	// Unit is meters if empty.
+	Unit GeoUnit
+	// Sort by distance, the results are not sorted if empty.
+	Sort  GeoSort
+	Count int
+	// Any returns the first Count matches found, which may not be the nearest ones.
+	Any bool
+}
+
+type GeoResult struct {
+	Member    string
+	Longitude float64
+	Latitude  float64
+	// Distance is in the unit of the query.
+	Distance float64
+	Hash     int64
+}
+
+// GeoKey keeps members with positions in a sorted set.
+type GeoKey struct {
+	*Key
+}
+
+func NewGeoKey(key string) *GeoKey {
+	k := geoKeyPool.Get().(*GeoKey)
+	k.Key = NewKey(key)
+	return k
+}
+
+func (k GeoKey) GeoAdd(ctx context.Context, locations ...GeoLocation) (int64, error) {
+	return k.GeoAddArgs(ctx, GeoAddArgs{}, locations...)
+}
+
+// GeoAddArgs returns how many members are added, updated members are counted with CH.
+func (k GeoKey) GeoAddArgs(ctx context.Context, args GeoAddArgs, locations ...GeoLocation) (int64, error) {
+	if len(locations) == 0 {
+		return 0, nil
+	}
+
+	parameters := make([]interface{}, 0, 5+len(locations)*3)
+	parameters = append(parameters, "geoadd", k.key)
+	if args.NX {
+		parameters = append(parameters, "nx")
+	}
+
+	if args.XX {
+		parameters = append(parameters, "xx")
+	}
+
+	if args.CH {
+		parameters = append(parameters, "ch")
+	}
+
+	for _, location := range locations {
+		parameters = append(parameters, location.Longitude, location.Latitude, location.Member)
+	}
+
+	start := time.Now()
+	count, err := MustGetRedis(ctx).Do(ctx, parameters...).Int64()
+	if err != nil {
+		log.Warn(ctx, "add locations failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Any("args", args),
+			log.Any("locations", locations),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "add locations successfully",
+		log.String("key", k.key),
+		log.Any("args", args),
+		log.Any("locations", locations),
+		log.Int64("count", count),
+		log.Duration("duration", time.Since(start)))
+
+	return count, nil
+}
+
+// GeoPos returns the positions of members, nil for the missing ones.
+func (k GeoKey) GeoPos(ctx context.Context, members ...string) ([]*GeoPosition, error) {
+	start := time.Now()
+	values, err := MustGetRedis(ctx).GeoPos(ctx, k.key, members...).Result()
+	if err != nil {
+		log.Warn(ctx, "get positions failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Strings("members", members),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, "get positions successfully",
+		log.String("key", k.key),
+		log.Strings("members", members),
+		log.Duration("duration", time.Since(start)))
+
+	positions := make([]*GeoPosition, len(values))
+	for index, value := range values {
+		if value != nil {
+			positions[index] = &GeoPosition{Longitude: value.Longitude, Latitude: value.Latitude}
+		}
+	}
+
+	return positions, nil
+}
+
+// GeoDist returns redis.Nil if any of the members is missing.
+func (k GeoKey) GeoDist(ctx context.Context, member1, member2 string, unit GeoUnit) (float64, error) {
+	if unit == "" {
+		unit = GeoMeters
+	}
+
+	start := time.Now()
+	distance, err := MustGetRedis(ctx).GeoDist(ctx, k.key, member1, member2, string(unit)).Result()
+	if err != nil {
+		log.Warn(ctx, "get distance failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("member1", member1),
+			log.String("member2", member2),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "get distance successfully",
+		log.String("key", k.key),
+		log.String("member1", member1),
+		log.String("member2", member2),
+		log.Float64("distance", distance),
+		log.Duration("duration", time.Since(start)))
+
+	return distance, nil
+}
+
+// GeoHash returns the 11 characters geohash of members, empty for the missing ones.
+func (k GeoKey) GeoHash(ctx context.Context, members ...string) ([]string, error) {
+	start := time.Now()
+	hashes, err := MustGetRedis(ctx).GeoHash(ctx, k.key, members...).Result()
+	if err != nil {
+		log.Warn(ctx, "get geohashes failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Strings("members", members),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, "get geohashes successfully",
+		log.String("key", k.key),
+		log.Strings("members", members),
+		log.Strings("hashes", hashes),
+		log.Duration("duration", time.Since(start)))
+
+	return hashes, nil
+}
+
+// GeoSearch returns the members in the circle or box of query with their positions and distances.
+func (k GeoKey) GeoSearch(ctx context.Context, query GeoSearchQuery) ([]GeoResult, error) {
+	start := time.Now()
+	locations, err := MustGetRedis(ctx).GeoSearchLocation(ctx, k.key, &redis.GeoSearchLocationQuery{
+		GeoSearchQuery: query.redisQuery(),
+		WithCoord:      true,
+		WithDist:       true,
+		WithHash:       true,
+	}).Result()
+	if err != nil {
+		log.Warn(ctx, "search locations failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Any("query", query),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, "search locations successfully",
+		log.String("key", k.key),
+		log.Any("query", query),
+		log.Int("count", len(locations)),
+		log.Duration("duration", time.Since(start)))
+
+	results := make([]GeoResult, len(locations))
+	for index, location := range locations {
+		results[index] = GeoResult{
+			Member:    location.Name,
+			Longitude: location.Longitude,
+			Latitude:  location.Latitude,
+			Distance:  location.Dist,
+			Hash:      location.GeoHash,
+		}
+	}
+
+	return results, nil
+}
+
+// GeoSearchStore stores the members found with their positions into destination, expiration 0 keeps destination persistent.
+func (k GeoKey) GeoSearchStore(ctx context.Context, destination *GeoKey, expiration time.Duration, query GeoSearchQuery) (int64, error) {
+	return k.geoSearchStore(ctx, destination.key, expiration, query, false)
+}
+
+// GeoSearchStoreDist stores the members found scored by their distances into destination.
+func (k GeoKey) GeoSearchStoreDist(ctx context.Context, destination *SortedSetKey, expiration time.Duration, query GeoSearchQuery) (int64, error) {
+	return k.geoSearchStore(ctx, destination.key, expiration, query, true)
+}
+
+func (k GeoKey) geoSearchStore(ctx context.Context, destination string, expiration time.Duration, query GeoSearchQuery, storeDist bool) (int64, error) {
+	start := time.Now()
+
+	var cmd *redis.IntCmd
+	_, err := MustGetRedis(ctx).TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		cmd = pipe.GeoSearchStore(ctx, k.key, destination, &redis.GeoSearchStoreQuery{
+			GeoSearchQuery: query.redisQuery(),
+			StoreDist:      storeDist,
+		})
+		if expiration > 0 {
+			pipe.PExpire(ctx, destination, expiration)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Warn(ctx, "search and store locations failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("destination", destination),
+			log.Any("query", query),
+			log.Duration("expiration", expiration),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	count := cmd.Val()
+	log.Debug(ctx, "search and store locations successfully",
+		log.String("key", k.key),
+		log.String("destination", destination),
+		log.Any("query", query),
+		log.Duration("expiration", expiration),
+		log.Int64("count", count),
+		log.Duration("duration", time.Since(start)))
+
+	return count, nil
+}
+
+func (k GeoKey) GeoRem(ctx context.Context, members ...string) (int64, error) {
+	return SortedSetKey{Key: k.Key}.ZRem(ctx, members...)
+}
+
+func (k GeoKey) GeoCard(ctx context.Context) (int64, error) {
+	return SortedSetKey{Key: k.Key}.ZCard(ctx)
+}
+
+func (q GeoSearchQuery) redisQuery() redis.GeoSearchQuery {
+	unit := q.Unit
+	if unit == "" {
+		unit = GeoMeters
+	}
+
+	query := redis.GeoSearchQuery{
+		Member:    q.Member,
+		Longitude: q.Longitude,
+		Latitude:  q.Latitude,
+		Sort:      string(q.Sort),
+		Count:     q.Count,
+		CountAny:  q.Any,
+	}
+
+	if q.Radius > 0 {
+		query.Radius, query.RadiusUnit = q.Radius, string(unit)
+	} else {
+		query.BoxWidth, query.BoxHeight, query.BoxUnit = q.Width, q.Height, string(unit)
+	}
+
+	return query
+}
+
+type GeoParameterKey struct {
+	*ParameterKey
+}
+
+func NewGeoParameterKey(pattern string) *GeoParameterKey {
+	k := geoParameterKeyPool.Get().(*GeoParameterKey)
+	k.ParameterKey = NewParameterKey(pattern)
+	return k
+}
+
+func (k GeoParameterKey) Param(parameters ...interface{}) *GeoKey {
+	return NewGeoKey(fmt.Sprintf(k.pattern, parameters...))
+}
+
+type ExpiringGeoKeyOption struct {
+	// Clock is the system clock if nil.
+	Clock Clock
+	// BatchSize is the max members removed by one expiration.
+	BatchSize int
+}
+
+// ExpiringGeoKey removes the positions not updated within ttl, the update times are kept in a companion sorted set.
+// Put a hash tag in key to keep both in the same cluster slot.
+type ExpiringGeoKey struct {
+	*GeoKey
+	updates *SortedSetKey
+	ttl     time.Duration
+	option  ExpiringGeoKeyOption
+}
+
+func NewExpiringGeoKey(key string, ttl time.Duration, option *ExpiringGeoKeyOption) *ExpiringGeoKey {
+	k := &ExpiringGeoKey{
+		GeoKey:  NewGeoKey(key),
+		updates: NewSortedSetKey(key + ":updated"),
+		ttl:     ttl,
+	}
+
+	if option != nil {
+		k.option = *option
+	}
+
+	if k.option.Clock == nil {
+		k.option.Clock = systemClock{}
+	}
+
+	if k.option.BatchSize <= 0 {
+		k.option.BatchSize = defaultExpiringGeoBatchSize
+	}
+
+	return k
+}
+
+// Updates returns the sorted set of the update times in unix milliseconds.
+func (k *ExpiringGeoKey) Updates() *SortedSetKey {
+	return k.updates
+}
+
+// Update adds or moves members and marks them updated now.
+func (k *ExpiringGeoKey) Update(ctx context.Context, locations ...GeoLocation) error {
+	if len(locations) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	now := k.option.Clock.Now()
+	geoLocations := make([]*redis.GeoLocation, len(locations))
+	updates := make([]redis.Z, len(locations))
+	for index, location := range locations {
+		geoLocations[index] = &redis.GeoLocation{Name: location.Member, Longitude: location.Longitude, Latitude: location.Latitude}
+		updates[index] = redis.Z{Member: location.Member, Score: float64(now.UnixMilli())}
+	}
+
+	_, err := MustGetRedis(ctx).TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.GeoAdd(ctx, k.key, geoLocations...)
+		pipe.ZAdd(ctx, k.updates.key, updates...)
+		return nil
+	})
+	if err != nil {
+		log.Warn(ctx, "update locations failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Any("locations", locations),
+			log.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	log.Debug(ctx, "update locations successfully",
+		log.String("key", k.key),
+		log.Any("locations", locations),
+		log.Time("now", now),
+		log.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+// UpdatedAt returns ErrRecordNotFound if the member has no position.
+func (k *ExpiringGeoKey) UpdatedAt(ctx context.Context, member string) (time.Time, error) {
+	score, err := k.updates.ZScore(ctx, member)
+	if err == redis.Nil {
+		return time.Time{}, ErrRecordNotFound
+	}
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.UnixMilli(int64(score)), nil
+}
+
+// Remove removes members with their update times.
+func (k *ExpiringGeoKey) Remove(ctx context.Context, members ...string) error {
+	start := time.Now()
+	_, err := MustGetRedis(ctx).TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, k.key, stringsToInterfaces(members)...)
+		pipe.ZRem(ctx, k.updates.key, stringsToInterfaces(members)...)
+		return nil
+	})
+	if err != nil {
+		log.Warn(ctx, "remove locations failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Strings("members", members),
+			log.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	log.Debug(ctx, "remove locations successfully",
+		log.String("key", k.key),
+		log.Strings("members", members),
+		log.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+// Expire removes a batch of the members not updated within ttl and returns them.
+func (k *ExpiringGeoKey) Expire(ctx context.Context) ([]string, error) {
+	start := time.Now()
+	before := k.option.Clock.Now().Add(-k.ttl)
+	members, err := geoExpireScript.Run(ctx, MustGetRedis(ctx), []string{k.key, k.updates.key},
+		before.UnixMilli(), k.option.BatchSize).StringSlice()
+	if err != nil {
+		log.Warn(ctx, "expire locations failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Time("before", before),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, "expire locations successfully",
+		log.String("key", k.key),
+		log.Time("before", before),
+		log.Strings("members", members),
+		log.Duration("duration", time.Since(start)))
+
+	return members, nil
+}
+
+// Run expires stale members every interval until ctx is done, full batches are followed by the next one at once.
+func (k *ExpiringGeoKey) Run(ctx context.Context, interval time.Duration) error {
+	for {
+		members, err := k.Expire(ctx)
+		if err != nil || len(members) < k.option.BatchSize {
+			err = sleepContext(ctx, interval)
+		} else {
+			err = ctx.Err()
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
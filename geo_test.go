@@ -0,0 +1,160 @@
+package ro
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var geoTestLocations = []GeoLocation{
+	{Member: "palermo", Longitude: 13.361389, Latitude: 38.115556},
+	{Member: "catania", Longitude: 15.087269, Latitude: 37.502669},
+	{Member: "rome", Longitude: 12.496366, Latitude: 41.902782},
+}
+
+func TestGeoKey(t *testing.T) {
+	ctx := context.Background()
+
+	k := NewGeoParameterKey("test:geo:%s")
+	couriers, nearby, distances := k.Param("couriers"), k.Param("nearby"), NewSortedSetKey("test:geo:distances")
+	defer func() {
+		_ = couriers.Del(ctx)
+		_ = nearby.Del(ctx)
+		_ = distances.Del(ctx)
+	}()
+
+	added, err := couriers.GeoAdd(ctx, geoTestLocations...)
+	if err != nil || added != 3 {
+		t.Errorf("geoadd failed, get %d, %v", added, err)
+	}
+
+	added, err = couriers.GeoAddArgs(ctx, GeoAddArgs{NX: true}, GeoLocation{Member: "rome", Longitude: 0, Latitude: 0})
+	if err != nil || added != 0 {
+		t.Errorf("geoadd nx should not update, get %d, %v", added, err)
+	}
+
+	added, err = couriers.GeoAddArgs(ctx, GeoAddArgs{XX: true, CH: true},
+		GeoLocation{Member: "rome", Longitude: 12.5, Latitude: 41.9},
+		GeoLocation{Member: "milan", Longitude: 9.19, Latitude: 45.4642})
+	if err != nil || added != 1 {
+		t.Errorf("geoadd xx ch should update only, get %d, %v", added, err)
+	}
+
+	positions, err := couriers.GeoPos(ctx, "palermo", "milan")
+	if err != nil || len(positions) != 2 || positions[1] != nil ||
+		math.Abs(positions[0].Longitude-13.361389) > 0.0001 || math.Abs(positions[0].Latitude-38.115556) > 0.0001 {
+		t.Errorf("geopos failed, get %+v, %v", positions, err)
+	}
+
+	distance, err := couriers.GeoDist(ctx, "palermo", "catania", GeoKilometers)
+	if err != nil || math.Abs(distance-166.27) > 0.1 {
+		t.Errorf("geodist failed, get %f, %v", distance, err)
+	}
+
+	_, err = couriers.GeoDist(ctx, "palermo", "milan", "")
+	if err != redis.Nil {
+		t.Errorf("geodist of missing member should return redis.Nil, get %v", err)
+	}
+
+	hashes, err := couriers.GeoHash(ctx, "palermo", "milan")
+	if err != nil || len(hashes) != 2 || !strings.HasPrefix(hashes[0], "sqc8b49rny") || hashes[1] != "" {
+		t.Errorf("geohash failed, get %v, %v", hashes, err)
+	}
+
+	results, err := couriers.GeoSearch(ctx, GeoSearchQuery{
+		Longitude: 15,
+		Latitude:  37,
+		Radius:    200,
+		Unit:      GeoKilometers,
+		Sort:      GeoAsc,
+	})
+	if err != nil || len(results) != 2 || results[0].Member != "catania" || results[1].Member != "palermo" ||
+		math.Abs(results[0].Distance-56.44) > 0.1 || results[0].Hash == 0 || math.Abs(results[1].Longitude-13.361389) > 0.0001 {
+		t.Errorf("geosearch by radius failed, get %+v, %v", results, err)
+	}
+
+	results, err = couriers.GeoSearch(ctx, GeoSearchQuery{
+		Member: "palermo",
+		Width:  1000,
+		Height: 1000,
+		Unit:   GeoKilometers,
+		Sort:   GeoDesc,
+		Count:  2,
+	})
+	if err != nil || len(results) != 2 || results[0].Member != "rome" || results[1].Member != "catania" {
+		t.Errorf("geosearch by box failed, get %+v, %v", results, err)
+	}
+
+	count, err := couriers.GeoSearchStore(ctx, nearby, time.Minute, GeoSearchQuery{Member: "catania", Radius: 200, Unit: GeoKilometers})
+	if err != nil || count != 2 {
+		t.Errorf("geosearchstore failed, get %d, %v", count, err)
+	}
+
+	positions, err = nearby.GeoPos(ctx, "palermo")
+	if err != nil || positions[0] == nil {
+		t.Errorf("stored member should have position, get %+v, %v", positions, err)
+	}
+
+	count, err = couriers.GeoSearchStoreDist(ctx, distances, 0, GeoSearchQuery{Member: "catania", Radius: 200, Unit: GeoKilometers})
+	score, _ := distances.ZScore(ctx, "palermo")
+	if err != nil || count != 2 || math.Abs(score-166.27) > 0.1 {
+		t.Errorf("geosearchstore with distances failed, get %d, %f, %v", count, score, err)
+	}
+
+	removed, err := couriers.GeoRem(ctx, "rome")
+	card, _ := couriers.GeoCard(ctx)
+	if err != nil || removed != 1 || card != 2 {
+		t.Errorf("georem failed, get %d, %d, %v", removed, card, err)
+	}
+}
+
+func TestExpiringGeoKey(t *testing.T) {
+	ctx := context.Background()
+
+	clock := &testClock{now: time.UnixMilli(1700000000000)}
+	k := NewExpiringGeoKey("test:{couriers}", time.Minute, &ExpiringGeoKeyOption{Clock: clock})
+	defer func() {
+		_ = k.Del(ctx)
+		_ = k.Updates().Del(ctx)
+	}()
+
+	err := k.Update(ctx, geoTestLocations[0], geoTestLocations[1])
+	if err != nil {
+		t.Fatalf("update failed, %v", err)
+	}
+
+	clock.Add(40 * time.Second)
+	_ = k.Update(ctx, geoTestLocations[1], geoTestLocations[2])
+
+	updatedAt, err := k.UpdatedAt(ctx, "catania")
+	if err != nil || !updatedAt.Equal(clock.Now()) {
+		t.Errorf("updated at failed, get %v, %v", updatedAt, err)
+	}
+
+	clock.Add(30 * time.Second)
+	expired, err := k.Expire(ctx)
+	if err != nil || len(expired) != 1 || expired[0] != "palermo" {
+		t.Errorf("expire failed, get %v, %v", expired, err)
+	}
+
+	positions, _ := k.GeoPos(ctx, "palermo", "catania")
+	if positions[0] != nil || positions[1] == nil {
+		t.Errorf("stale position should be removed, get %+v", positions)
+	}
+
+	_, err = k.UpdatedAt(ctx, "palermo")
+	if err != ErrRecordNotFound {
+		t.Errorf("update time of stale member should be removed, get %v", err)
+	}
+
+	err = k.Remove(ctx, "rome")
+	card, _ := k.GeoCard(ctx)
+	updates, _ := k.Updates().ZCard(ctx)
+	if err != nil || card != 1 || updates != 1 {
+		t.Errorf("remove failed, get %d, %d, %v", card, updates, err)
+	}
+}
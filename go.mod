@@ -3,6 +3,7 @@ module github.com/nzai/ro
 go 1.22
 
 require (
+	github.com/klauspost/compress v1.18.0
 	github.com/nzai/log v1.2.1
 	github.com/redis/go-redis/v9 v9.7.0
 )
@@ -2,12 +2,13 @@ package ro
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
 )
 
 var (
@@ -60,12 +61,16 @@ func (k HashSetKey) HGetObject(ctx context.Context, field string, value interfac
 		return err
 	}
 
-	err = json.Unmarshal([]byte(result), value)
+	upgraded, err := unmarshalObject(result, value)
 	if err != nil {
 		log.Warn(ctx, "json unmarshal failed", log.Err(err), log.String("result", result))
 		return err
 	}
 
+	if upgraded != "" {
+		k.writeBackObject(ctx, field, result, upgraded)
+	}
+
 	log.Debug(ctx, "get object successfully",
 		log.String("key", k.key),
 		log.String("field", field),
@@ -136,8 +141,13 @@ func (k HashSetKey) HGetAll(ctx context.Context) (map[string]string, error) {
 }
 
 func (k HashSetKey) HSet(ctx context.Context, field, value string) error {
+	_, err := k.HSetCount(ctx, field, value)
+	return err
+}
+
+func (k HashSetKey) HSetCount(ctx context.Context, field, value string) (int64, error) {
 	start := time.Now()
-	err := MustGetRedis(ctx).HSet(ctx, k.key, field, value).Err()
+	count, err := MustGetRedis(ctx).HSet(ctx, k.key, field, value).Result()
 	if err != nil {
 		log.Warn(ctx, "set value failed",
 			log.Err(err),
@@ -145,20 +155,98 @@ func (k HashSetKey) HSet(ctx context.Context, field, value string) error {
 			log.String("field", field),
 			log.String("value", value),
 			log.Duration("duration", time.Since(start)))
-		return err
+		return 0, err
 	}
 
 	log.Debug(ctx, "set value successfully",
 		log.String("key", k.key),
 		log.String("field", field),
 		log.String("value", value),
+		log.Int64("count", count),
 		log.Duration("duration", time.Since(start)))
 
-	return nil
+	return count, nil
+}
+
+func (k HashSetKey) HSetMap(ctx context.Context, values map[string]string) (int64, error) {
+	parameters := make(map[string]interface{}, len(values))
+	for field, value := range values {
+		parameters[field] = value
+	}
+
+	return k.hSetValues(ctx, parameters)
+}
+
+func (k HashSetKey) hSetValues(ctx context.Context, values map[string]interface{}) (int64, error) {
+	if len(values) == 0 {
+		return 0, nil
+	}
+
+	if len(values) > getChunkOption().ChunkSize {
+		return k.hSetValuesInChunks(ctx, values)
+	}
+
+	start := time.Now()
+	count, err := MustGetRedis(ctx).HSet(ctx, k.key, values).Result()
+	if err != nil {
+		log.Warn(ctx, "set values failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Any("values", values),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "set values successfully",
+		log.String("key", k.key),
+		log.Any("values", values),
+		log.Int64("count", count),
+		log.Duration("duration", time.Since(start)))
+
+	return count, nil
+}
+
+func (k HashSetKey) hSetValuesInChunks(ctx context.Context, values map[string]interface{}) (int64, error) {
+	fields := make([]string, 0, len(values))
+	for field := range values {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return chunkedMembers(ctx, "set values", k.key, fields, func(ctx context.Context, pipe redis.Pipeliner, fields []string) *redis.IntCmd {
+		pairs := make([]interface{}, 0, len(fields)*2)
+		for _, field := range fields {
+			pairs = append(pairs, field, values[field])
+		}
+		return pipe.HSet(ctx, k.key, pairs...)
+	})
+}
+
+func (k HashSetKey) HSetNX(ctx context.Context, field, value string) (bool, error) {
+	start := time.Now()
+	success, err := MustGetRedis(ctx).HSetNX(ctx, k.key, field, value).Result()
+	if err != nil {
+		log.Warn(ctx, "setnx field value failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("field", field),
+			log.String("value", value),
+			log.Duration("duration", time.Since(start)))
+		return false, err
+	}
+
+	log.Debug(ctx, "setnx field value finished",
+		log.String("key", k.key),
+		log.String("field", field),
+		log.String("value", value),
+		log.Bool("success", success),
+		log.Duration("duration", time.Since(start)))
+
+	return success, nil
 }
 
 func (k HashSetKey) HSetObject(ctx context.Context, field string, value interface{}) error {
-	buffer, err := json.Marshal(value)
+	buffer, err := marshalObject(value)
 	if err != nil {
 		log.Warn(ctx, "json marshal failed", log.Err(err), log.Any("value", value))
 		return err
@@ -168,27 +256,207 @@ func (k HashSetKey) HSetObject(ctx context.Context, field string, value interfac
 }
 
 func (k HashSetKey) HDel(ctx context.Context, field ...string) error {
+	_, err := k.HDelCount(ctx, field...)
+	return err
+}
+
+func (k HashSetKey) HDelCount(ctx context.Context, field ...string) (int64, error) {
 	if len(field) == 0 {
-		return nil
+		return 0, nil
+	}
+
+	if len(field) > getChunkOption().ChunkSize {
+		return chunkedMembers(ctx, "del field", k.key, field, func(ctx context.Context, pipe redis.Pipeliner, fields []string) *redis.IntCmd {
+			return pipe.HDel(ctx, k.key, fields...)
+		})
 	}
 
 	start := time.Now()
-	err := MustGetRedis(ctx).HDel(ctx, k.key, field...).Err()
+	count, err := MustGetRedis(ctx).HDel(ctx, k.key, field...).Result()
 	if err != nil {
 		log.Warn(ctx, "del field failed",
 			log.Err(err),
 			log.String("key", k.key),
 			log.Strings("field", field),
 			log.Duration("duration", time.Since(start)))
-		return err
+		return 0, err
 	}
 
 	log.Debug(ctx, "del field successfully",
 		log.String("key", k.key),
 		log.Strings("field", field),
+		log.Int64("count", count),
 		log.Duration("duration", time.Since(start)))
 
-	return nil
+	return count, nil
+}
+
+func (k HashSetKey) HExists(ctx context.Context, field string) (bool, error) {
+	start := time.Now()
+	exists, err := MustGetRedis(ctx).HExists(ctx, k.key, field).Result()
+	if err != nil {
+		log.Warn(ctx, "check field exists failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("field", field),
+			log.Duration("duration", time.Since(start)))
+		return false, err
+	}
+
+	log.Debug(ctx, "check field exists successfully",
+		log.String("key", k.key),
+		log.String("field", field),
+		log.Bool("exists", exists),
+		log.Duration("duration", time.Since(start)))
+
+	return exists, nil
+}
+
+func (k HashSetKey) HIncrBy(ctx context.Context, field string, value int64) (int64, error) {
+	start := time.Now()
+	newValue, err := MustGetRedis(ctx).HIncrBy(ctx, k.key, field, value).Result()
+	if err != nil {
+		log.Warn(ctx, "increase field by value failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("field", field),
+			log.Int64("value", value),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "increase field by value successfully",
+		log.String("key", k.key),
+		log.String("field", field),
+		log.Int64("value", value),
+		log.Int64("newValue", newValue),
+		log.Duration("duration", time.Since(start)))
+
+	return newValue, nil
+}
+
+func (k HashSetKey) HIncrByFloat(ctx context.Context, field string, value float64) (float64, error) {
+	start := time.Now()
+	newValue, err := MustGetRedis(ctx).HIncrByFloat(ctx, k.key, field, value).Result()
+	if err != nil {
+		log.Warn(ctx, "increase field by float value failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("field", field),
+			log.Float64("value", value),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "increase field by float value successfully",
+		log.String("key", k.key),
+		log.String("field", field),
+		log.Float64("value", value),
+		log.Float64("newValue", newValue),
+		log.Duration("duration", time.Since(start)))
+
+	return newValue, nil
+}
+
+func (k HashSetKey) HKeys(ctx context.Context) ([]string, error) {
+	start := time.Now()
+	fields, err := MustGetRedis(ctx).HKeys(ctx, k.key).Result()
+	if err != nil {
+		log.Warn(ctx, "get fields failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, "get fields successfully",
+		log.String("key", k.key),
+		log.Strings("fields", fields),
+		log.Duration("duration", time.Since(start)))
+
+	return fields, nil
+}
+
+func (k HashSetKey) HVals(ctx context.Context) ([]string, error) {
+	start := time.Now()
+	values, err := MustGetRedis(ctx).HVals(ctx, k.key).Result()
+	if err != nil {
+		log.Warn(ctx, "get values failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, "get values successfully",
+		log.String("key", k.key),
+		log.Strings("values", values),
+		log.Duration("duration", time.Since(start)))
+
+	return values, nil
+}
+
+func (k HashSetKey) HStrLen(ctx context.Context, field string) (int64, error) {
+	start := time.Now()
+	length, err := MustGetRedis(ctx).Do(ctx, "HSTRLEN", k.key, field).Int64()
+	if err != nil {
+		log.Warn(ctx, "get field value length failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("field", field),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "get field value length successfully",
+		log.String("key", k.key),
+		log.String("field", field),
+		log.Int64("length", length),
+		log.Duration("duration", time.Since(start)))
+
+	return length, nil
+}
+
+func (k HashSetKey) HRandField(ctx context.Context, count int) ([]string, error) {
+	start := time.Now()
+	fields, err := MustGetRedis(ctx).HRandField(ctx, k.key, count).Result()
+	if err != nil {
+		log.Warn(ctx, "get random fields failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int("count", count),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, "get random fields successfully",
+		log.String("key", k.key),
+		log.Int("count", count),
+		log.Strings("fields", fields),
+		log.Duration("duration", time.Since(start)))
+
+	return fields, nil
+}
+
+func (k HashSetKey) HRandFieldWithValues(ctx context.Context, count int) ([]redis.KeyValue, error) {
+	start := time.Now()
+	values, err := MustGetRedis(ctx).HRandFieldWithValues(ctx, k.key, count).Result()
+	if err != nil {
+		log.Warn(ctx, "get random fields with values failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int("count", count),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, "get random fields with values successfully",
+		log.String("key", k.key),
+		log.Int("count", count),
+		log.Any("values", values),
+		log.Duration("duration", time.Since(start)))
+
+	return values, nil
 }
 
 func (k HashSetKey) HLen(ctx context.Context) (int64, error) {
@@ -210,13 +478,81 @@ func (k HashSetKey) HLen(ctx context.Context) (int64, error) {
 	return count, nil
 }
 
+func (k HashSetKey) HScanIterator(cursor uint64, match string, count int64) HashScanIterator {
+	return HashScanIterator{newScanIterator(cursor, 2, func(ctx context.Context, cursor uint64) ([]string, uint64, error) {
+		start := time.Now()
+		values, next, err := MustGetRedis(ctx).HScan(ctx, k.key, cursor, match, count).Result()
+		if err != nil {
+			log.Warn(ctx, "scan fields failed",
+				log.Err(err),
+				log.String("key", k.key),
+				log.Uint64("cursor", cursor),
+				log.String("match", match),
+				log.Duration("duration", time.Since(start)))
+			return nil, 0, err
+		}
+
+		log.Debug(ctx, "scan fields successfully",
+			log.String("key", k.key),
+			log.Uint64("cursor", cursor),
+			log.String("match", match),
+			log.Uint64("next", next),
+			log.Int("count", len(values)/2),
+			log.Duration("duration", time.Since(start)))
+
+		return values, next, nil
+	})}
+}
+
+func HMGetObjects[T any](ctx context.Context, k *HashSetKey, fields ...string) (map[string]T, error) {
+	values, err := k.HMGet(ctx, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeHashObjects[T](ctx, k, values)
+}
+
+func HGetAllObjects[T any](ctx context.Context, k *HashSetKey) (map[string]T, error) {
+	values, err := k.HGetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeHashObjects[T](ctx, k, values)
+}
+
+func decodeHashObjects[T any](ctx context.Context, k *HashSetKey, values map[string]string) (map[string]T, error) {
+	result := make(map[string]T, len(values))
+	for field, value := range values {
+		var obj T
+		upgraded, err := unmarshalObject(value, &obj)
+		if err != nil {
+			log.Warn(ctx, "json unmarshal failed",
+				log.Err(err),
+				log.String("key", k.key),
+				log.String("field", field),
+				log.String("value", value))
+			return nil, err
+		}
+
+		if upgraded != "" {
+			k.writeBackObject(ctx, field, value, upgraded)
+		}
+
+		result[field] = obj
+	}
+
+	return result, nil
+}
+
 type HashSetParameterKey struct {
-	pattern string
+	*ParameterKey
 }
 
 func NewHashSetParameterKey(pattern string) *HashSetParameterKey {
 	k := hashSetParameterKeyPool.Get().(*HashSetParameterKey)
-	k.pattern = pattern
+	k.ParameterKey = NewParameterKey(pattern)
 	return k
 }
 
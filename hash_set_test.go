@@ -64,3 +64,101 @@ func TestHashSetKey_HMGet(t *testing.T) {
 	}
 
 }
+
+func TestHashSetKey_HashCommands(t *testing.T) {
+	ctx := context.Background()
+
+	k := NewHashSetKey("hs222")
+	defer k.Del(ctx)
+
+	count, err := k.HSetMap(ctx, map[string]string{"a": "1", "b": "22"})
+	if err != nil || count != 2 {
+		t.Errorf("set map failed, get %d, %v", count, err)
+	}
+
+	count, err = k.HSetCount(ctx, "a", "11")
+	if err != nil || count != 0 {
+		t.Errorf("update field should not add new field, get %d, %v", count, err)
+	}
+
+	success, err := k.HSetNX(ctx, "a", "111")
+	if err != nil || success {
+		t.Errorf("setnx exists field should fail, get %v, %v", success, err)
+	}
+
+	exists, err := k.HExists(ctx, "c")
+	if err != nil || exists {
+		t.Errorf("field c should not exists, get %v, %v", exists, err)
+	}
+
+	value, err := k.HIncrBy(ctx, "a", 4)
+	if err != nil || value != 15 {
+		t.Errorf("increase field failed, get %d, %v", value, err)
+	}
+
+	floatValue, err := k.HIncrByFloat(ctx, "c", 0.5)
+	if err != nil || floatValue != 0.5 {
+		t.Errorf("increase field by float failed, get %v, %v", floatValue, err)
+	}
+
+	length, err := k.HStrLen(ctx, "b")
+	if err != nil || length != 2 {
+		t.Errorf("get field length failed, get %d, %v", length, err)
+	}
+
+	fields, err := k.HKeys(ctx)
+	if err != nil || len(fields) != 3 {
+		t.Errorf("get fields failed, get %v, %v", fields, err)
+	}
+
+	values, err := k.HVals(ctx)
+	if err != nil || len(values) != 3 {
+		t.Errorf("get values failed, get %v, %v", values, err)
+	}
+
+	fields, err = k.HRandField(ctx, 2)
+	if err != nil || len(fields) != 2 {
+		t.Errorf("get random fields failed, get %v, %v", fields, err)
+	}
+
+	count, err = k.HDelCount(ctx, "a", "b", "not exists")
+	if err != nil || count != 2 {
+		t.Errorf("del fields failed, get %d, %v", count, err)
+	}
+}
+
+func TestHMGetObjects(t *testing.T) {
+	ctx := context.Background()
+
+	k := NewHashSetKey("hs333")
+	defer k.Del(ctx)
+
+	want := map[string]testStruct{
+		"1": {AAA: "a", BBB: 1},
+		"2": {AAA: "b", BBB: 2},
+	}
+	for field, value := range want {
+		err := k.HSetObject(ctx, field, value)
+		if err != nil {
+			t.Errorf("set object failed due to %v", err)
+		}
+	}
+
+	got, err := HMGetObjects[testStruct](ctx, k, "1", "2", "3")
+	if err != nil {
+		t.Errorf("get objects failed due to %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HMGetObjects() = %v, want %v", got, want)
+	}
+
+	got, err = HGetAllObjects[testStruct](ctx, k)
+	if err != nil {
+		t.Errorf("get all objects failed due to %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HGetAllObjects() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,371 @@
+package ro
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nzai/log"
+)
+
+var structFieldsCache sync.Map
+
+const (
+	structCodecJSON = "json"
+	structCodecGob  = "gob"
+)
+
+// structField maps a struct field to a hash field, configured by tags like
+// `ro:"name"`, `ro:"age,omitempty"`, `ro:"address,gob"` or `ro:"-"`.
+// Scalars are stored as plain strings so numbers work with HINCRBY,
+// other types are stored as json unless a codec is given in the tag.
+type structField struct {
+	index     []int
+	name      string
+	omitEmpty bool
+	codec     string
+}
+
+func (k HashSetKey) HSetStruct(ctx context.Context, obj interface{}) error {
+	value, fields, err := structValue(obj)
+	if err != nil {
+		log.Warn(ctx, "invalid struct", log.Err(err), log.Any("obj", obj))
+		return err
+	}
+
+	values := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		raw, skip, err := encodeStructField(value, field)
+		if err != nil {
+			log.Warn(ctx, "encode struct field failed",
+				log.Err(err),
+				log.String("key", k.key),
+				log.String("field", field.name))
+			return err
+		}
+
+		if !skip {
+			values[field.name] = raw
+		}
+	}
+
+	_, err = k.hSetValues(ctx, values)
+	return err
+}
+
+// HUpdateStruct writes only the fields changed from previous to current,
+// and removes fields which became empty.
+func (k HashSetKey) HUpdateStruct(ctx context.Context, previous, current interface{}) (int, error) {
+	previousValue, fields, err := structValue(previous)
+	if err != nil {
+		log.Warn(ctx, "invalid struct", log.Err(err), log.Any("previous", previous))
+		return 0, err
+	}
+
+	currentValue, _, err := structValue(current)
+	if err != nil || currentValue.Type() != previousValue.Type() {
+		log.Warn(ctx, "invalid struct", log.Err(err), log.Any("current", current))
+		return 0, ErrBadRequest
+	}
+
+	values := make(map[string]interface{})
+	var deleted []string
+	for _, field := range fields {
+		previousRaw, previousSkip, err := encodeStructField(previousValue, field)
+		if err != nil {
+			return 0, err
+		}
+
+		currentRaw, currentSkip, err := encodeStructField(currentValue, field)
+		if err != nil {
+			return 0, err
+		}
+
+		switch {
+		case currentSkip && !previousSkip:
+			deleted = append(deleted, field.name)
+		case !currentSkip && (previousSkip || previousRaw != currentRaw):
+			values[field.name] = currentRaw
+		}
+	}
+
+	if len(values) > 0 {
+		_, err = k.hSetValues(ctx, values)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if len(deleted) > 0 {
+		err = k.HDel(ctx, deleted...)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(values) + len(deleted), nil
+}
+
+func (k HashSetKey) HGetStruct(ctx context.Context, obj interface{}) error {
+	values, err := k.HGetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(values) == 0 {
+		return ErrRecordNotFound
+	}
+
+	return k.decodeStruct(ctx, values, obj)
+}
+
+func (k HashSetKey) HGetStructFields(ctx context.Context, obj interface{}, fields ...string) error {
+	values, err := k.HMGet(ctx, fields)
+	if err != nil {
+		return err
+	}
+
+	return k.decodeStruct(ctx, values, obj)
+}
+
+func (k HashSetKey) decodeStruct(ctx context.Context, values map[string]string, obj interface{}) error {
+	value, fields, err := structValue(obj)
+	if err != nil || reflect.ValueOf(obj).Kind() != reflect.Pointer {
+		log.Warn(ctx, "invalid struct pointer", log.Err(err), log.Any("obj", obj))
+		return ErrBadRequest
+	}
+
+	for _, field := range fields {
+		raw, found := values[field.name]
+		if !found {
+			continue
+		}
+
+		err = decodeStructField(value, field, raw)
+		if err != nil {
+			log.Warn(ctx, "decode struct field failed",
+				log.Err(err),
+				log.String("key", k.key),
+				log.String("field", field.name),
+				log.String("value", raw))
+			return err
+		}
+	}
+
+	return nil
+}
+
+func structValue(obj interface{}) (reflect.Value, []structField, error) {
+	value := reflect.ValueOf(obj)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return reflect.Value{}, nil, ErrBadRequest
+		}
+
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return reflect.Value{}, nil, ErrBadRequest
+	}
+
+	return value, getStructFields(value.Type()), nil
+}
+
+func getStructFields(t reflect.Type) []structField {
+	if cached, found := structFieldsCache.Load(t); found {
+		return cached.([]structField)
+	}
+
+	var fields []structField
+	for _, field := range reflect.VisibleFields(t) {
+		tag, tagged := field.Tag.Lookup("ro")
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+
+		if field.Anonymous && !tagged && indirectType(field.Type).Kind() == reflect.Struct {
+			// promoted fields are listed by VisibleFields
+			continue
+		}
+
+		options := strings.Split(tag, ",")
+		f := structField{
+			index: field.Index,
+			name:  options[0],
+		}
+		if f.name == "" {
+			f.name = field.Name
+		}
+
+		for _, option := range options[1:] {
+			switch option {
+			case "omitempty":
+				f.omitEmpty = true
+			case structCodecJSON, structCodecGob:
+				f.codec = option
+			}
+		}
+
+		fields = append(fields, f)
+	}
+
+	structFieldsCache.Store(t, fields)
+	return fields
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// encodeStructField returns skip when the field should not be stored.
+func encodeStructField(obj reflect.Value, field structField) (string, bool, error) {
+	value, err := obj.FieldByIndexErr(field.index)
+	if err != nil {
+		// nil embedded pointer
+		return "", true, nil
+	}
+
+	if value.Kind() == reflect.Pointer && value.IsNil() || field.omitEmpty && value.IsZero() {
+		return "", true, nil
+	}
+
+	raw, err := encodeStructValue(value, field.codec)
+	return raw, false, err
+}
+
+func encodeStructValue(value reflect.Value, codec string) (string, error) {
+	switch codec {
+	case structCodecJSON:
+		buffer, err := json.Marshal(value.Interface())
+		return string(buffer), err
+	case structCodecGob:
+		buffer := new(bytes.Buffer)
+		err := gob.NewEncoder(buffer).Encode(value.Interface())
+		return buffer.String(), err
+	}
+
+	for value.Kind() == reflect.Pointer {
+		value = value.Elem()
+	}
+
+	if marshaler, ok := value.Interface().(encoding.TextMarshaler); ok {
+		buffer, err := marshaler.MarshalText()
+		return string(buffer), err
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 64), nil
+	case reflect.Slice:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return string(value.Bytes()), nil
+		}
+	}
+
+	buffer, err := json.Marshal(value.Interface())
+	return string(buffer), err
+}
+
+func decodeStructField(obj reflect.Value, field structField, raw string) error {
+	value := obj
+	for _, index := range field.index {
+		if value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+
+			value = value.Elem()
+		}
+
+		value = value.Field(index)
+	}
+
+	return decodeStructValue(value, field.codec, raw)
+}
+
+func decodeStructValue(value reflect.Value, codec string, raw string) error {
+	switch codec {
+	case structCodecJSON:
+		return json.Unmarshal([]byte(raw), value.Addr().Interface())
+	case structCodecGob:
+		return gob.NewDecoder(strings.NewReader(raw)).Decode(value.Addr().Interface())
+	}
+
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+
+		value = value.Elem()
+	}
+
+	if unmarshaler, ok := value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(raw))
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+		return nil
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+
+		value.SetBool(parsed)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		value.SetInt(parsed)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		value.SetUint(parsed)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		value.SetFloat(parsed)
+		return nil
+	case reflect.Slice:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			value.SetBytes([]byte(raw))
+			return nil
+		}
+	}
+
+	return json.Unmarshal([]byte(raw), value.Addr().Interface())
+}
@@ -0,0 +1,132 @@
+package ro
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testHashAddress struct {
+	City   string
+	Street string
+}
+
+type testHashBase struct {
+	ID int64 `ro:"id"`
+}
+
+type testHashUser struct {
+	testHashBase
+	Name     string            `ro:"name"`
+	Age      int               `ro:"age,omitempty"`
+	Score    float64           `ro:"score"`
+	Birthday time.Time         `ro:"birthday"`
+	Address  *testHashAddress  `ro:"address"`
+	Extra    map[string]string `ro:"extra,gob"`
+	Nickname *string           `ro:"nickname"`
+	Ignored  string            `ro:"-"`
+}
+
+func TestHashSetKey_HSetAndHGetStruct(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewHashSetKey("hash:struct:1")
+	defer key.Del(ctx)
+
+	user := &testHashUser{
+		testHashBase: testHashBase{ID: 1},
+		Name:         "tom",
+		Age:          18,
+		Score:        9.5,
+		Birthday:     time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC),
+		Address:      &testHashAddress{City: "a", Street: "b"},
+		Extra:        map[string]string{"k": "v"},
+		Ignored:      "ignored",
+	}
+
+	err := key.HSetStruct(ctx, user)
+	if err != nil {
+		t.Fatalf("set struct failed due to %v", err)
+	}
+
+	values, err := key.HGetAll(ctx)
+	if err != nil {
+		t.Fatalf("get all failed due to %v", err)
+	}
+
+	if values["id"] != "1" || values["age"] != "18" || values["address"] != `{"City":"a","Street":"b"}` {
+		t.Errorf("get unexpect hash values %v", values)
+	}
+
+	if _, found := values["nickname"]; found {
+		t.Errorf("nil pointer should not be stored")
+	}
+
+	if _, found := values["Ignored"]; found {
+		t.Errorf("ignored field should not be stored")
+	}
+
+	err = MustGetRedis(ctx).HIncrBy(ctx, key.key, "age", 2).Err()
+	if err != nil {
+		t.Fatalf("increase numeric field failed due to %v", err)
+	}
+
+	get := &testHashUser{}
+	err = key.HGetStruct(ctx, get)
+	if err != nil {
+		t.Fatalf("get struct failed due to %v", err)
+	}
+
+	user.Age += 2
+	user.Ignored = ""
+	if !reflect.DeepEqual(get, user) {
+		t.Errorf("get struct not equal, want %+v, get %+v", user, get)
+	}
+
+	partial := &testHashUser{}
+	err = key.HGetStructFields(ctx, partial, "name", "score")
+	if err != nil {
+		t.Fatalf("get struct fields failed due to %v", err)
+	}
+
+	if partial.Name != "tom" || partial.Score != 9.5 || partial.Age != 0 {
+		t.Errorf("get unexpect struct fields %+v", partial)
+	}
+}
+
+func TestHashSetKey_HUpdateStruct(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewHashSetKey("hash:struct:2")
+	defer key.Del(ctx)
+
+	previous := &testHashUser{Name: "tom", Age: 18}
+	err := key.HSetStruct(ctx, previous)
+	if err != nil {
+		t.Fatalf("set struct failed due to %v", err)
+	}
+
+	nickname := "tommy"
+	current := *previous
+	current.Age = 0
+	current.Nickname = &nickname
+
+	changed, err := key.HUpdateStruct(ctx, previous, &current)
+	if err != nil {
+		t.Fatalf("update struct failed due to %v", err)
+	}
+
+	if changed != 2 {
+		t.Errorf("get unexpect changed fields, want 2, get %d", changed)
+	}
+
+	values, err := key.HGetAll(ctx)
+	if err != nil {
+		t.Fatalf("get all failed due to %v", err)
+	}
+
+	if _, found := values["age"]; found || values["nickname"] != "tommy" || values["name"] != "tom" {
+		t.Errorf("get unexpect hash values %v", values)
+	}
+}
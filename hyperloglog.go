@@ -0,0 +1,266 @@
+package ro
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	hyperLogLogKeyPool = sync.Pool{
+		New: func() interface{} {
+			return &HyperLogLogKey{}
+		},
+	}
+	hyperLogLogParameterKeyPool = sync.Pool{
+		New: func() interface{} {
+			return &HyperLogLogParameterKey{}
+		},
+	}
+)
+
+// HyperLogLogKey estimates the count of unique elements in about 12KB.
+type HyperLogLogKey struct {
+	*Key
+}
+
+func NewHyperLogLogKey(key string) *HyperLogLogKey {
+	k := hyperLogLogKeyPool.Get().(*HyperLogLogKey)
+	k.Key = NewKey(key)
+	return k
+}
+
+// PFAdd returns true if the estimated count is changed.
+func (k HyperLogLogKey) PFAdd(ctx context.Context, elements ...string) (bool, error) {
+	start := time.Now()
+	changed, err := MustGetRedis(ctx).PFAdd(ctx, k.key, stringsToInterfaces(elements)...).Result()
+	if err != nil {
+		log.Warn(ctx, "add elements failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Strings("elements", elements),
+			log.Duration("duration", time.Since(start)))
+		return false, err
+	}
+
+	log.Debug(ctx, "add elements successfully",
+		log.String("key", k.key),
+		log.Strings("elements", elements),
+		log.Bool("changed", changed > 0),
+		log.Duration("duration", time.Since(start)))
+
+	return changed > 0, nil
+}
+
+// PFCount returns the estimated count of the union of k and others.
+func (k HyperLogLogKey) PFCount(ctx context.Context, others ...*HyperLogLogKey) (int64, error) {
+	start := time.Now()
+	keys := k.withOthers(others)
+	count, err := MustGetRedis(ctx).PFCount(ctx, keys...).Result()
+	if err != nil {
+		log.Warn(ctx, "count elements failed",
+			log.Err(err),
+			log.Strings("keys", keys),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "count elements successfully",
+		log.Strings("keys", keys),
+		log.Int64("count", count),
+		log.Duration("duration", time.Since(start)))
+
+	return count, nil
+}
+
+// PFMerge merges sources into k, expiration 0 keeps k persistent.
+func (k HyperLogLogKey) PFMerge(ctx context.Context, expiration time.Duration, sources ...*HyperLogLogKey) error {
+	start := time.Now()
+	keys := make([]string, len(sources))
+	for index, source := range sources {
+		keys[index] = source.key
+	}
+
+	_, err := MustGetRedis(ctx).TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.PFMerge(ctx, k.key, keys...)
+		if expiration > 0 {
+			pipe.PExpire(ctx, k.key, expiration)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Warn(ctx, "merge elements failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Strings("sources", keys),
+			log.Duration("expiration", expiration),
+			log.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	log.Debug(ctx, "merge elements successfully",
+		log.String("key", k.key),
+		log.Strings("sources", keys),
+		log.Duration("expiration", expiration),
+		log.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+func (k HyperLogLogKey) withOthers(others []*HyperLogLogKey) []string {
+	keys := make([]string, 0, len(others)+1)
+	keys = append(keys, k.key)
+	for _, other := range others {
+		keys = append(keys, other.key)
+	}
+
+	return keys
+}
+
+type HyperLogLogParameterKey struct {
+	*ParameterKey
+}
+
+func NewHyperLogLogParameterKey(pattern string) *HyperLogLogParameterKey {
+	k := hyperLogLogParameterKeyPool.Get().(*HyperLogLogParameterKey)
+	k.ParameterKey = NewParameterKey(pattern)
+	return k
+}
+
+func (k HyperLogLogParameterKey) Param(parameters ...interface{}) *HyperLogLogKey {
+	return NewHyperLogLogKey(fmt.Sprintf(k.pattern, parameters...))
+}
+
+type DailyHyperLogLogOption struct {
+	// Location decides the day boundaries, UTC if nil.
+	Location *time.Location
+	// Retention is how long a day is kept after it ends, forever if zero.
+	Retention time.Duration
+}
+
+// DailyHyperLogLog keeps one HyperLogLog per day, put a hash tag in key to keep all days in the same cluster slot.
+type DailyHyperLogLog struct {
+	key    string
+	option DailyHyperLogLogOption
+}
+
+func NewDailyHyperLogLog(key string, option *DailyHyperLogLogOption) *DailyHyperLogLog {
+	d := &DailyHyperLogLog{key: key}
+	if option != nil {
+		d.option = *option
+	}
+
+	if d.option.Location == nil {
+		d.option.Location = time.UTC
+	}
+
+	return d
+}
+
+// Day returns the HyperLogLog of the day of t.
+func (d *DailyHyperLogLog) Day(t time.Time) *HyperLogLogKey {
+	return NewHyperLogLogKey(d.key + ":" + t.In(d.option.Location).Format("20060102"))
+}
+
+// Add adds elements to the day of t, it returns true if the estimated count of the day is changed.
+func (d *DailyHyperLogLog) Add(ctx context.Context, t time.Time, elements ...string) (bool, error) {
+	day := d.Day(t)
+	if d.option.Retention <= 0 {
+		return day.PFAdd(ctx, elements...)
+	}
+
+	start := time.Now()
+	expireAt := CalendarDay.End(t.In(d.option.Location)).Add(d.option.Retention)
+
+	var cmd *redis.IntCmd
+	_, err := MustGetRedis(ctx).TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		cmd = pipe.PFAdd(ctx, day.key, stringsToInterfaces(elements)...)
+		pipe.PExpireAt(ctx, day.key, expireAt)
+		return nil
+	})
+	if err != nil {
+		log.Warn(ctx, "add daily elements failed",
+			log.Err(err),
+			log.String("key", day.key),
+			log.Strings("elements", elements),
+			log.Time("expireAt", expireAt),
+			log.Duration("duration", time.Since(start)))
+		return false, err
+	}
+
+	log.Debug(ctx, "add daily elements successfully",
+		log.String("key", day.key),
+		log.Strings("elements", elements),
+		log.Time("expireAt", expireAt),
+		log.Duration("duration", time.Since(start)))
+
+	return cmd.Val() > 0, nil
+}
+
+// Count returns the estimated unique elements of the day of t.
+func (d *DailyHyperLogLog) Count(ctx context.Context, t time.Time) (int64, error) {
+	return d.Day(t).PFCount(ctx)
+}
+
+// CountLastDays returns the estimated unique elements of the days days ending with the day of end,
+// they are merged into a temporary key which is deleted in the same transaction.
+func (d *DailyHyperLogLog) CountLastDays(ctx context.Context, end time.Time, days int) (int64, error) {
+	if days <= 0 {
+		return 0, nil
+	}
+
+	start := time.Now()
+	keys := d.lastDays(end, days)
+	temporary := temporaryKey(d.key)
+
+	var cmd *redis.IntCmd
+	_, err := MustGetRedis(ctx).TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.PFMerge(ctx, temporary, keys...)
+		cmd = pipe.PFCount(ctx, temporary)
+		pipe.Del(ctx, temporary)
+		return nil
+	})
+	if err != nil {
+		log.Warn(ctx, "count daily elements failed",
+			log.Err(err),
+			log.Strings("keys", keys),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	count := cmd.Val()
+	log.Debug(ctx, "count daily elements successfully",
+		log.Strings("keys", keys),
+		log.Int64("count", count),
+		log.Duration("duration", time.Since(start)))
+
+	return count, nil
+}
+
+// MergeLastDays merges the days days ending with the day of end into destination, expiration 0 keeps destination persistent.
+func (d *DailyHyperLogLog) MergeLastDays(ctx context.Context, destination *HyperLogLogKey, end time.Time, days int, expiration time.Duration) error {
+	if days <= 0 {
+		return nil
+	}
+
+	sources := make([]*HyperLogLogKey, 0, days)
+	for _, key := range d.lastDays(end, days) {
+		sources = append(sources, NewHyperLogLogKey(key))
+	}
+
+	return destination.PFMerge(ctx, expiration, sources...)
+}
+
+func (d *DailyHyperLogLog) lastDays(end time.Time, days int) []string {
+	end = end.In(d.option.Location)
+	keys := make([]string, days)
+	for index := range keys {
+		keys[index] = d.Day(end.AddDate(0, 0, -index)).key
+	}
+
+	return keys
+}
@@ -0,0 +1,107 @@
+package ro
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestHyperLogLogKey(t *testing.T) {
+	ctx := context.Background()
+
+	k := NewHyperLogLogParameterKey("test:hll:%d")
+	first, second, merged := k.Param(1), k.Param(2), k.Param(3)
+	defer func() {
+		_ = first.Del(ctx)
+		_ = second.Del(ctx)
+		_ = merged.Del(ctx)
+	}()
+
+	changed, err := first.PFAdd(ctx, "a", "b", "c")
+	if err != nil || !changed {
+		t.Errorf("pfadd failed, get %v, %v", changed, err)
+	}
+
+	changed, err = first.PFAdd(ctx, "a")
+	if err != nil || changed {
+		t.Errorf("pfadd existed element should not change, get %v, %v", changed, err)
+	}
+
+	_, _ = second.PFAdd(ctx, "c", "d")
+
+	count, err := first.PFCount(ctx)
+	if err != nil || count != 3 {
+		t.Errorf("pfcount failed, get %d, %v", count, err)
+	}
+
+	count, err = first.PFCount(ctx, second)
+	if err != nil || count != 4 {
+		t.Errorf("pfcount of keys failed, get %d, %v", count, err)
+	}
+
+	err = merged.PFMerge(ctx, time.Minute, first, second)
+	if err != nil {
+		t.Errorf("pfmerge failed, %v", err)
+	}
+
+	count, err = merged.PFCount(ctx)
+	if err != nil || count != 4 {
+		t.Errorf("pfcount of merged failed, get %d, %v", count, err)
+	}
+
+	ttl, err := merged.TTL(ctx)
+	if err != nil || ttl <= 0 {
+		t.Errorf("merged should expire, get %v, %v", ttl, err)
+	}
+}
+
+func TestDailyHyperLogLog(t *testing.T) {
+	ctx := context.Background()
+
+	d := NewDailyHyperLogLog("test:{uv:home}", &DailyHyperLogLogOption{Retention: 48 * time.Hour})
+	today := time.Now()
+	defer func() {
+		for index := 0; index < 3; index++ {
+			_ = d.Day(today.AddDate(0, 0, -index)).Del(ctx)
+		}
+	}()
+
+	for index := 0; index < 3; index++ {
+		day := today.AddDate(0, 0, -index)
+		for user := index; user < index+10; user++ {
+			_, err := d.Add(ctx, day, fmt.Sprintf("user:%d", user))
+			if err != nil {
+				t.Fatalf("add failed, %v", err)
+			}
+		}
+	}
+
+	count, err := d.Count(ctx, today)
+	if err != nil || count != 10 {
+		t.Errorf("count today failed, get %d, %v", count, err)
+	}
+
+	count, err = d.CountLastDays(ctx, today, 3)
+	if err != nil || count != 12 {
+		t.Errorf("count last days failed, get %d, %v", count, err)
+	}
+
+	keys, err := MustGetRedis(ctx).Keys(ctx, "test:{uv:home}*tmp*").Result()
+	if err != nil || len(keys) != 0 {
+		t.Errorf("temporary key should be deleted, get %v, %v", keys, err)
+	}
+
+	merged := NewHyperLogLogKey("test:{uv:home}:last2")
+	defer merged.Del(ctx)
+
+	err = d.MergeLastDays(ctx, merged, today, 2, 0)
+	if err != nil {
+		t.Errorf("merge last days failed, %v", err)
+	}
+
+	count, err = merged.PFCount(ctx)
+	if err != nil || count != 11 {
+		t.Errorf("count merged days failed, get %d, %v", count, err)
+	}
+}
@@ -2,6 +2,7 @@ package ro
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,6 +15,11 @@ var (
 			return &Key{}
 		},
 	}
+	parameterKeyPool = sync.Pool{
+		New: func() interface{} {
+			return &ParameterKey{}
+		},
+	}
 )
 
 type Key struct {
@@ -124,3 +130,53 @@ func (k Key) Exists(ctx context.Context) (bool, error) {
 func (k Key) Key() string {
 	return k.key
 }
+
+type ParameterKey struct {
+	pattern string
+}
+
+func NewParameterKey(pattern string) *ParameterKey {
+	k := parameterKeyPool.Get().(*ParameterKey)
+	k.pattern = pattern
+	return k
+}
+
+func (k ParameterKey) Pattern() string {
+	return k.pattern
+}
+
+// Match converts the fmt pattern into a glob pattern for SCAN and PSUBSCRIBE,
+// e.g. user:%d:cart becomes user:*:cart.
+func (k ParameterKey) Match() string {
+	match := make([]rune, 0, len(k.pattern))
+	runes := []rune(k.pattern)
+	// lastWildcard tells apart a wildcard from an escaped literal *
+	lastWildcard := false
+	for index := 0; index < len(runes); index++ {
+		r := runes[index]
+		switch {
+		case r == '%' && index+1 < len(runes) && runes[index+1] == '%':
+			match = append(match, '%')
+			index++
+			lastWildcard = false
+		case r == '%':
+			// skip flags, width and precision until the verb
+			for index+1 < len(runes) && strings.ContainsRune("+-# 0123456789.*[]", runes[index+1]) {
+				index++
+			}
+			index++
+			if !lastWildcard {
+				match = append(match, '*')
+			}
+			lastWildcard = true
+		case strings.ContainsRune(`*?[]\`, r):
+			match = append(match, '\\', r)
+			lastWildcard = false
+		default:
+			match = append(match, r)
+			lastWildcard = false
+		}
+	}
+
+	return string(match)
+}
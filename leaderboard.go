@@ -0,0 +1,439 @@
+package ro
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	LeaderboardKeyPattern         = "ro:leaderboard:{%s}"
+	LeaderboardMetadataKeyPattern = "ro:leaderboard:%s:metadata"
+
+	// leaderboardSequenceWidth is the width of the inverted submission sequence prefixed to members.
+	leaderboardSequenceWidth = 15
+)
+
+type LeaderboardPolicy string
+
+const (
+	// LeaderboardBest keeps the highest score.
+	LeaderboardBest LeaderboardPolicy = "best"
+	// LeaderboardLatest keeps the last submitted score.
+	LeaderboardLatest LeaderboardPolicy = "latest"
+	// LeaderboardSum adds up the submitted scores.
+	LeaderboardSum LeaderboardPolicy = "sum"
+)
+
+var (
+	// leaderboardSubmitScript KEYS are the scores sorted set, the id to member hash and the sequence of every board.
+	// ARGV[1] is the id, ARGV[2] is the score, ARGV[3] is the policy, ARGV[3+n] is the unix milliseconds to expire the board n at, 0 to keep.
+	// Members are the inverted submission sequence and the id, so equal scores rank the earliest submission first in reverse order.
+	// It returns the score kept on the first board.
+	leaderboardSubmitScript = redis.NewScript(`
+local function submit(scores, members, sequence, expireAt)
+	local score = ARGV[2]
+	local previous = redis.call('HGET', members, ARGV[1])
+	if previous then
+		local previousScore = redis.call('ZSCORE', scores, previous)
+		if previousScore then
+			if ARGV[3] == 'best' and tonumber(score) <= tonumber(previousScore) then
+				return previousScore
+			end
+			if ARGV[3] == 'sum' then
+				score = tonumber(score) + tonumber(previousScore)
+			end
+		end
+		redis.call('ZREM', scores, previous)
+	end
+
+	local member = string.format('%0` + strconv.Itoa(leaderboardSequenceWidth) + `.0f', 999999999999999 - redis.call('INCR', sequence)) .. ':' .. ARGV[1]
+	redis.call('ZADD', scores, score, member)
+	redis.call('HSET', members, ARGV[1], member)
+	if tonumber(expireAt) > 0 then
+		for _, key in ipairs({scores, members, sequence}) do
+			redis.call('PEXPIREAT', key, expireAt)
+		end
+	end
+	return redis.call('ZSCORE', scores, member)
+end
+
+local current
+for index = 1, #KEYS, 3 do
+	local score = submit(KEYS[index], KEYS[index + 1], KEYS[index + 2], ARGV[3 + (index + 2) / 3])
+	if index == 1 then
+		current = score
+	end
+end
+return current
+`)
+
+	// leaderboardRankScript returns the reverse rank and the score of ARGV[1], KEYS are the scores sorted set and the id to member hash.
+	leaderboardRankScript = redis.NewScript(`
+local member = redis.call('HGET', KEYS[2], ARGV[1])
+if not member then
+	return false
+end
+local rank = redis.call('ZREVRANK', KEYS[1], member)
+if not rank then
+	return false
+end
+return {rank, redis.call('ZSCORE', KEYS[1], member)}
+`)
+
+	// leaderboardRemoveScript removes ARGV[1], KEYS are the scores sorted set and the id to member hash.
+	leaderboardRemoveScript = redis.NewScript(`
+local member = redis.call('HGET', KEYS[2], ARGV[1])
+if not member then
+	return 0
+end
+redis.call('HDEL', KEYS[2], ARGV[1])
+return redis.call('ZREM', KEYS[1], member)
+`)
+)
+
+type LeaderboardOption struct {
+	// Window splits the leaderboard by calendar periods, 0 keeps a single all-time board.
+	Window CalendarWindow
+	// Location decides the period boundaries, UTC if nil.
+	Location *time.Location
+	// Retention keeps a period board after its end.
+	Retention time.Duration
+	// AllTime also submits scores of periodic boards to the all-time board, in the same script as the period board.
+	// The all-time board applies the policy to every submission instead of merging the period boards,
+	// so it is kept after the period boards expire.
+	AllTime bool
+}
+
+// Leaderboard ranks ids by score, ties are ranked by the earliest submission.
+type Leaderboard struct {
+	name     string
+	policy   LeaderboardPolicy
+	option   LeaderboardOption
+	metadata *HashSetKey
+}
+
+type LeaderboardEntry struct {
+	ID    string
+	Score float64
+	// Rank starts from 1.
+	Rank     int64
+	Metadata string
+}
+
+func (e LeaderboardEntry) MetadataObject(obj interface{}) error {
+	_, err := unmarshalObject(e.Metadata, obj)
+	return err
+}
+
+func NewLeaderboard(name string, policy LeaderboardPolicy, option *LeaderboardOption) *Leaderboard {
+	lb := &Leaderboard{
+		name:     name,
+		policy:   policy,
+		metadata: NewHashSetKey(fmt.Sprintf(LeaderboardMetadataKeyPattern, name)),
+	}
+
+	if option != nil {
+		lb.option = *option
+	}
+
+	if lb.option.Location == nil {
+		lb.option.Location = time.UTC
+	}
+
+	return lb
+}
+
+// Metadata returns the hash of the metadata of ids, which is shared by all periods.
+func (lb *Leaderboard) Metadata() *HashSetKey {
+	return lb.metadata
+}
+
+func (lb *Leaderboard) SetMetadata(ctx context.Context, id, metadata string) error {
+	return lb.metadata.HSet(ctx, id, metadata)
+}
+
+func (lb *Leaderboard) SetMetadataObject(ctx context.Context, id string, obj interface{}) error {
+	return lb.metadata.HSetObject(ctx, id, obj)
+}
+
+// Submit applies score to the current period, it returns the score kept by the policy.
+func (lb *Leaderboard) Submit(ctx context.Context, id string, score float64) (float64, error) {
+	return lb.SubmitAt(ctx, id, score, time.Now())
+}
+
+// SubmitAt applies score to the period of t, and to the all-time board atomically if option.AllTime is set.
+func (lb *Leaderboard) SubmitAt(ctx context.Context, id string, score float64, t time.Time) (float64, error) {
+	views := []*LeaderboardView{lb.At(t)}
+	if lb.option.Window != 0 && lb.option.AllTime {
+		views = append(views, lb.AllTime())
+	}
+
+	return submitLeaderboard(ctx, views, id, score)
+}
+
+// At returns the board of the period of t, or the all-time board if the leaderboard is not periodic.
+func (lb *Leaderboard) At(t time.Time) *LeaderboardView {
+	if lb.option.Window == 0 {
+		return lb.AllTime()
+	}
+
+	t = t.In(lb.option.Location)
+	return lb.view(lb.name+":"+lb.periodID(t), lb.option.Window.End(t).Add(lb.option.Retention))
+}
+
+func (lb *Leaderboard) Current() *LeaderboardView {
+	return lb.At(time.Now())
+}
+
+func (lb *Leaderboard) AllTime() *LeaderboardView {
+	return lb.view(lb.name, time.Time{})
+}
+
+func (lb *Leaderboard) periodID(t time.Time) string {
+	start := lb.option.Window.Start(t)
+	switch lb.option.Window {
+	case CalendarHour:
+		return start.Format("2006010215")
+	case CalendarMonth:
+		return start.Format("200601")
+	default:
+		return start.Format("20060102")
+	}
+}
+
+func (lb *Leaderboard) view(name string, expireAt time.Time) *LeaderboardView {
+	key := fmt.Sprintf(LeaderboardKeyPattern, name)
+	return &LeaderboardView{
+		leaderboard: lb,
+		scores:      NewSortedSetKey(key),
+		members:     key + ":members",
+		sequence:    key + ":sequence",
+		expireAt:    expireAt,
+	}
+}
+
+// LeaderboardView is the board of one period.
+type LeaderboardView struct {
+	leaderboard *Leaderboard
+	scores      *SortedSetKey
+	members     string
+	sequence    string
+	expireAt    time.Time
+}
+
+func (v *LeaderboardView) keys() []string {
+	return []string{v.scores.key, v.members, v.sequence}
+}
+
+// submitLeaderboard applies score to every view in one script, it returns the score kept on the first view.
+func submitLeaderboard(ctx context.Context, views []*LeaderboardView, id string, score float64) (float64, error) {
+	keys := make([]string, 0, len(views)*3)
+	args := []interface{}{id, score, string(views[0].leaderboard.policy)}
+	for _, view := range views {
+		var expireAt int64
+		if !view.expireAt.IsZero() {
+			expireAt = view.expireAt.UnixMilli()
+		}
+
+		keys = append(keys, view.keys()...)
+		args = append(args, expireAt)
+	}
+
+	start := time.Now()
+	current, err := leaderboardSubmitScript.Run(ctx, MustGetRedis(ctx), keys, args...).Float64()
+	if err != nil {
+		log.Warn(ctx, "submit leaderboard score failed",
+			log.Err(err),
+			log.Strings("keys", keys),
+			log.String("id", id),
+			log.Float64("score", score),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "submit leaderboard score successfully",
+		log.Strings("keys", keys),
+		log.String("id", id),
+		log.Float64("score", score),
+		log.Float64("current", current),
+		log.Duration("duration", time.Since(start)))
+
+	return current, nil
+}
+
+// Rank returns the entry of id, it returns ErrRecordNotFound if id has no score.
+func (v *LeaderboardView) Rank(ctx context.Context, id string) (*LeaderboardEntry, error) {
+	start := time.Now()
+	values, err := leaderboardRankScript.Run(ctx, MustGetRedis(ctx), v.keys()[:2], id).Slice()
+	if err == redis.Nil {
+		log.Debug(ctx, "leaderboard rank not found",
+			log.String("key", v.scores.key),
+			log.String("id", id),
+			log.Duration("duration", time.Since(start)))
+		return nil, ErrRecordNotFound
+	}
+
+	if err == nil && len(values) != 2 {
+		err = ErrInvalidResultCount
+	}
+
+	var rank int64
+	var score float64
+	if err == nil {
+		rank, score, err = parseLeaderboardRank(values)
+	}
+
+	if err != nil {
+		log.Warn(ctx, "get leaderboard rank failed",
+			log.Err(err),
+			log.String("key", v.scores.key),
+			log.String("id", id),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	entries := []LeaderboardEntry{{ID: id, Score: score, Rank: rank + 1}}
+	err = v.attachMetadata(ctx, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entries[0], nil
+}
+
+func parseLeaderboardRank(values []interface{}) (int64, float64, error) {
+	rank, ok := values[0].(int64)
+	if !ok {
+		return 0, 0, ErrInvalidEncodedValue
+	}
+
+	raw, ok := values[1].(string)
+	if !ok {
+		return 0, 0, ErrInvalidEncodedValue
+	}
+
+	score, err := strconv.ParseFloat(raw, 64)
+	return rank, score, err
+}
+
+// Around returns id and up to count entries above and below it.
+func (v *LeaderboardView) Around(ctx context.Context, id string, count int64) ([]LeaderboardEntry, error) {
+	entry, err := v.Rank(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := entry.Rank - 1 - count
+	if offset < 0 {
+		offset = 0
+	}
+
+	return v.Top(ctx, offset, entry.Rank+count-offset)
+}
+
+// Top returns count entries from offset, the highest score first.
+func (v *LeaderboardView) Top(ctx context.Context, offset, count int64) ([]LeaderboardEntry, error) {
+	if count <= 0 {
+		return nil, nil
+	}
+
+	zs, err := v.scores.ZRangeWithScores(ctx, ZRangeQuery{Start: offset, Stop: offset + count - 1, Rev: true})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]LeaderboardEntry, len(zs))
+	for index, z := range zs {
+		member, ok := z.Member.(string)
+		if !ok || len(member) <= leaderboardSequenceWidth+1 {
+			log.Warn(ctx, "invalid leaderboard member",
+				log.String("key", v.scores.key),
+				log.Any("member", z.Member))
+			return nil, ErrInvalidEncodedValue
+		}
+
+		entries[index] = LeaderboardEntry{
+			ID:    member[leaderboardSequenceWidth+1:],
+			Score: z.Score,
+			Rank:  offset + int64(index) + 1,
+		}
+	}
+
+	err = v.attachMetadata(ctx, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (v *LeaderboardView) attachMetadata(ctx context.Context, entries []LeaderboardEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(entries))
+	for index, entry := range entries {
+		ids[index] = entry.ID
+	}
+
+	metadata, err := v.leaderboard.metadata.HMGet(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for index := range entries {
+		entries[index].Metadata = metadata[entries[index].ID]
+	}
+
+	return nil
+}
+
+func (v *LeaderboardView) Remove(ctx context.Context, id string) (bool, error) {
+	start := time.Now()
+	removed, err := leaderboardRemoveScript.Run(ctx, MustGetRedis(ctx), v.keys()[:2], id).Int64()
+	if err != nil {
+		log.Warn(ctx, "remove leaderboard id failed",
+			log.Err(err),
+			log.String("key", v.scores.key),
+			log.String("id", id),
+			log.Duration("duration", time.Since(start)))
+		return false, err
+	}
+
+	log.Debug(ctx, "remove leaderboard id successfully",
+		log.String("key", v.scores.key),
+		log.String("id", id),
+		log.Bool("removed", removed > 0),
+		log.Duration("duration", time.Since(start)))
+
+	return removed > 0, nil
+}
+
+func (v *LeaderboardView) Count(ctx context.Context) (int64, error) {
+	return v.scores.ZCard(ctx)
+}
+
+// Del deletes the board, the metadata is kept.
+func (v *LeaderboardView) Del(ctx context.Context) error {
+	start := time.Now()
+	err := MustGetRedis(ctx).Del(ctx, v.keys()...).Err()
+	if err != nil {
+		log.Warn(ctx, "delete leaderboard failed",
+			log.Err(err),
+			log.String("key", v.scores.key),
+			log.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	log.Debug(ctx, "delete leaderboard successfully",
+		log.String("key", v.scores.key),
+		log.Duration("duration", time.Since(start)))
+
+	return nil
+}
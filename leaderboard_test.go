@@ -0,0 +1,168 @@
+package ro
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type leaderboardTestPlayer struct {
+	Name string
+}
+
+func TestLeaderboard_Policies(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		policy LeaderboardPolicy
+		scores []float64
+		want   float64
+	}{
+		{policy: LeaderboardBest, scores: []float64{10, 30, 20}, want: 30},
+		{policy: LeaderboardLatest, scores: []float64{10, 30, 20}, want: 20},
+		{policy: LeaderboardSum, scores: []float64{10, 30, 20.5}, want: 60.5},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			lb := NewLeaderboard("test:policy:"+string(tt.policy), tt.policy, nil)
+			defer lb.AllTime().Del(ctx)
+
+			var current float64
+			var err error
+			for _, score := range tt.scores {
+				current, err = lb.Submit(ctx, "p1", score)
+				if err != nil {
+					t.Fatalf("submit failed due to %v", err)
+				}
+			}
+
+			entry, err := lb.Current().Rank(ctx, "p1")
+			if err != nil || current != tt.want || entry.Score != tt.want || entry.Rank != 1 {
+				t.Errorf("submit with policy %s failed, get %v %+v, %v", tt.policy, current, entry, err)
+			}
+		})
+	}
+}
+
+func TestLeaderboard_Rank(t *testing.T) {
+	ctx := context.Background()
+
+	lb := NewLeaderboard("test:rank", LeaderboardBest, nil)
+	board := lb.AllTime()
+	defer board.Del(ctx)
+	defer lb.Metadata().Del(ctx)
+
+	for _, player := range []struct {
+		id    string
+		score float64
+	}{{"a", 50}, {"z", 70}, {"c", 70}, {"b", 70}, {"e", 30}, {"f", 10}} {
+		_, err := lb.Submit(ctx, player.id, player.score)
+		if err != nil {
+			t.Fatalf("submit failed due to %v", err)
+		}
+	}
+
+	err := lb.SetMetadataObject(ctx, "c", &leaderboardTestPlayer{Name: "Carol"})
+	if err != nil {
+		t.Fatalf("set metadata failed due to %v", err)
+	}
+
+	entries, err := board.Top(ctx, 0, 4)
+	if err != nil || len(entries) != 4 {
+		t.Fatalf("top failed, get %+v, %v", entries, err)
+	}
+
+	for index, id := range []string{"z", "c", "b", "a"} {
+		if entries[index].ID != id || entries[index].Rank != int64(index+1) {
+			t.Errorf("ties should rank the earliest submission first, get %+v", entries)
+			break
+		}
+	}
+
+	player := new(leaderboardTestPlayer)
+	err = entries[1].MetadataObject(player)
+	if err != nil || player.Name != "Carol" {
+		t.Errorf("metadata should be attached, get %+v, %v", player, err)
+	}
+
+	entry, err := board.Rank(ctx, "b")
+	if err != nil || entry.Rank != 3 || entry.Score != 70 {
+		t.Errorf("rank failed, get %+v, %v", entry, err)
+	}
+
+	_, err = board.Rank(ctx, "missing")
+	if err != ErrRecordNotFound {
+		t.Errorf("rank of missing id should return ErrRecordNotFound, get %v", err)
+	}
+
+	entries, err = board.Around(ctx, "a", 1)
+	if err != nil || len(entries) != 3 || entries[0].ID != "b" || entries[1].ID != "a" || entries[2].ID != "e" {
+		t.Errorf("around failed, get %+v, %v", entries, err)
+	}
+
+	entries, err = board.Around(ctx, "z", 2)
+	if err != nil || len(entries) != 3 || entries[0].Rank != 1 {
+		t.Errorf("around the first should start from the top, get %+v, %v", entries, err)
+	}
+
+	entries, err = board.Top(ctx, 4, 10)
+	if err != nil || len(entries) != 2 || entries[1].ID != "f" || entries[1].Rank != 6 {
+		t.Errorf("top page failed, get %+v, %v", entries, err)
+	}
+
+	removed, err := board.Remove(ctx, "z")
+	if err != nil || !removed {
+		t.Errorf("remove failed, get %v, %v", removed, err)
+	}
+
+	count, err := board.Count(ctx)
+	if err != nil || count != 5 {
+		t.Errorf("count failed, get %d, %v", count, err)
+	}
+}
+
+func TestLeaderboard_Periodic(t *testing.T) {
+	ctx := context.Background()
+
+	lb := NewLeaderboard("test:periodic", LeaderboardSum, &LeaderboardOption{
+		Window:    CalendarDay,
+		Retention: time.Hour,
+		AllTime:   true,
+	})
+
+	now := time.Now()
+	yesterday := now.AddDate(0, 0, -1)
+	defer lb.At(now).Del(ctx)
+	defer lb.At(yesterday).Del(ctx)
+	defer lb.AllTime().Del(ctx)
+
+	_, _ = lb.SubmitAt(ctx, "p1", 10, yesterday)
+	_, _ = lb.Submit(ctx, "p2", 8)
+
+	// the score of the period board is returned
+	current, err := lb.Submit(ctx, "p1", 5)
+	if err != nil || current != 5 {
+		t.Errorf("submit failed, get %v, %v", current, err)
+	}
+
+	entry, err := lb.Current().Rank(ctx, "p1")
+	if err != nil || entry.Score != 5 || entry.Rank != 2 {
+		t.Errorf("daily board failed, get %+v, %v", entry, err)
+	}
+
+	entry, err = lb.AllTime().Rank(ctx, "p1")
+	if err != nil || entry.Score != 15 || entry.Rank != 1 {
+		t.Errorf("all-time board failed, get %+v, %v", entry, err)
+	}
+
+	ttl, err := lb.Current().scores.TTL(ctx)
+	end := CalendarDay.End(now.UTC()).Add(time.Hour)
+	if err != nil || ttl <= 0 || ttl > time.Until(end)+time.Second {
+		t.Errorf("daily board should expire after retention, get %v, %v", ttl, err)
+	}
+
+	ttl, err = lb.AllTime().scores.TTL(ctx)
+	if err != nil || ttl >= 0 {
+		t.Errorf("all-time board should be persistent, get %v, %v", ttl, err)
+	}
+}
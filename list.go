@@ -0,0 +1,574 @@
+package ro
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	ListLeft  = "LEFT"
+	ListRight = "RIGHT"
+)
+
+var (
+	listKeyPool = sync.Pool{
+		New: func() interface{} {
+			return &ListKey{}
+		},
+	}
+	listParameterKeyPool = sync.Pool{
+		New: func() interface{} {
+			return &ListParameterKey{}
+		},
+	}
+)
+
+type ListKey struct {
+	*Key
+}
+
+func NewListKey(key string) *ListKey {
+	k := listKeyPool.Get().(*ListKey)
+	k.Key = NewKey(key)
+	return k
+}
+
+func (k ListKey) LPush(ctx context.Context, values ...string) (int64, error) {
+	return k.push(ctx, "lpush", values, MustGetRedis(ctx).LPush)
+}
+
+func (k ListKey) RPush(ctx context.Context, values ...string) (int64, error) {
+	return k.push(ctx, "rpush", values, MustGetRedis(ctx).RPush)
+}
+
+// LPushX pushes only if the list exists, it returns 0 otherwise.
+func (k ListKey) LPushX(ctx context.Context, values ...string) (int64, error) {
+	return k.push(ctx, "lpushx", values, MustGetRedis(ctx).LPushX)
+}
+
+// RPushX pushes only if the list exists, it returns 0 otherwise.
+func (k ListKey) RPushX(ctx context.Context, values ...string) (int64, error) {
+	return k.push(ctx, "rpushx", values, MustGetRedis(ctx).RPushX)
+}
+
+func (k ListKey) push(ctx context.Context, operation string, values []string, fn func(context.Context, string, ...interface{}) *redis.IntCmd) (int64, error) {
+	if len(values) == 0 {
+		return 0, nil
+	}
+
+	start := time.Now()
+	length, err := fn(ctx, k.key, stringsToInterfaces(values)...).Result()
+	if err != nil {
+		log.Warn(ctx, operation+" failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int("count", len(values)),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, operation+" successfully",
+		log.String("key", k.key),
+		log.Int("count", len(values)),
+		log.Int64("length", length),
+		log.Duration("duration", time.Since(start)))
+
+	return length, nil
+}
+
+// LPop returns redis.Nil if the list is empty.
+func (k ListKey) LPop(ctx context.Context) (string, error) {
+	return k.pop(ctx, "lpop", MustGetRedis(ctx).LPop)
+}
+
+// RPop returns redis.Nil if the list is empty.
+func (k ListKey) RPop(ctx context.Context) (string, error) {
+	return k.pop(ctx, "rpop", MustGetRedis(ctx).RPop)
+}
+
+func (k ListKey) pop(ctx context.Context, operation string, fn func(context.Context, string) *redis.StringCmd) (string, error) {
+	start := time.Now()
+	value, err := fn(ctx, k.key).Result()
+	if err != nil {
+		log.Warn(ctx, operation+" failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Duration("duration", time.Since(start)))
+		return "", err
+	}
+
+	log.Debug(ctx, operation+" successfully",
+		log.String("key", k.key),
+		log.String("value", value),
+		log.Duration("duration", time.Since(start)))
+
+	return value, nil
+}
+
+// LPopCount returns redis.Nil if the list is empty.
+func (k ListKey) LPopCount(ctx context.Context, count int) ([]string, error) {
+	return k.popCount(ctx, "lpop count", count, MustGetRedis(ctx).LPopCount)
+}
+
+// RPopCount returns redis.Nil if the list is empty.
+func (k ListKey) RPopCount(ctx context.Context, count int) ([]string, error) {
+	return k.popCount(ctx, "rpop count", count, MustGetRedis(ctx).RPopCount)
+}
+
+func (k ListKey) popCount(ctx context.Context, operation string, count int, fn func(context.Context, string, int) *redis.StringSliceCmd) ([]string, error) {
+	start := time.Now()
+	values, err := fn(ctx, k.key, count).Result()
+	if err != nil {
+		log.Warn(ctx, operation+" failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int("count", count),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, operation+" successfully",
+		log.String("key", k.key),
+		log.Int("count", count),
+		log.Strings("values", values),
+		log.Duration("duration", time.Since(start)))
+
+	return values, nil
+}
+
+// BLPop pops from the first non-empty list of k and others, it returns the key and the value.
+// It returns ErrRecordNotFound when timeout, 0 timeout blocks until the ctx deadline if any.
+func (k ListKey) BLPop(ctx context.Context, timeout time.Duration, others ...*ListKey) (string, string, error) {
+	return k.blockingPop(ctx, "blpop", timeout, others, MustGetRedis(ctx).BLPop)
+}
+
+func (k ListKey) BRPop(ctx context.Context, timeout time.Duration, others ...*ListKey) (string, string, error) {
+	return k.blockingPop(ctx, "brpop", timeout, others, MustGetRedis(ctx).BRPop)
+}
+
+func (k ListKey) blockingPop(ctx context.Context, operation string, timeout time.Duration, others []*ListKey, fn func(context.Context, time.Duration, ...string) *redis.StringSliceCmd) (string, string, error) {
+	keys := make([]string, 0, len(others)+1)
+	keys = append(keys, k.key)
+	for _, other := range others {
+		keys = append(keys, other.key)
+	}
+
+	timeout, err := blockingTimeout(ctx, timeout)
+	if err != nil {
+		return "", "", err
+	}
+
+	start := time.Now()
+	reply, err := fn(ctx, timeout, keys...).Result()
+	if err == redis.Nil {
+		log.Debug(ctx, operation+" no value found",
+			log.Strings("keys", keys),
+			log.Duration("timeout", timeout),
+			log.Duration("duration", time.Since(start)))
+		return "", "", ErrRecordNotFound
+	}
+
+	if err == nil && len(reply) != 2 {
+		err = ErrInvalidResultCount
+	}
+
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+
+		log.Warn(ctx, operation+" failed",
+			log.Err(err),
+			log.Strings("keys", keys),
+			log.Duration("timeout", timeout),
+			log.Duration("duration", time.Since(start)))
+		return "", "", err
+	}
+
+	log.Debug(ctx, operation+" successfully",
+		log.String("key", reply[0]),
+		log.String("value", reply[1]),
+		log.Duration("duration", time.Since(start)))
+
+	return reply[0], reply[1], nil
+}
+
+// LMove moves an item from the from side of k to the to side of destination, from and to are ListLeft or ListRight.
+// It returns redis.Nil if the list is empty.
+func (k ListKey) LMove(ctx context.Context, destination *ListKey, from, to string) (string, error) {
+	start := time.Now()
+	value, err := MustGetRedis(ctx).LMove(ctx, k.key, destination.key, from, to).Result()
+	if err != nil {
+		log.Warn(ctx, "lmove failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("destination", destination.key),
+			log.Duration("duration", time.Since(start)))
+		return "", err
+	}
+
+	log.Debug(ctx, "lmove successfully",
+		log.String("key", k.key),
+		log.String("destination", destination.key),
+		log.String("value", value),
+		log.Duration("duration", time.Since(start)))
+
+	return value, nil
+}
+
+// BLMove is the blocking LMove, it returns ErrRecordNotFound when timeout.
+func (k ListKey) BLMove(ctx context.Context, destination *ListKey, from, to string, timeout time.Duration) (string, error) {
+	timeout, err := blockingTimeout(ctx, timeout)
+	if err != nil {
+		return "", err
+	}
+
+	start := time.Now()
+	value, err := MustGetRedis(ctx).BLMove(ctx, k.key, destination.key, from, to, timeout).Result()
+	if err == redis.Nil {
+		log.Debug(ctx, "blmove no value found",
+			log.String("key", k.key),
+			log.String("destination", destination.key),
+			log.Duration("timeout", timeout),
+			log.Duration("duration", time.Since(start)))
+		return "", ErrRecordNotFound
+	}
+
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+
+		log.Warn(ctx, "blmove failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("destination", destination.key),
+			log.Duration("timeout", timeout),
+			log.Duration("duration", time.Since(start)))
+		return "", err
+	}
+
+	log.Debug(ctx, "blmove successfully",
+		log.String("key", k.key),
+		log.String("destination", destination.key),
+		log.String("value", value),
+		log.Duration("duration", time.Since(start)))
+
+	return value, nil
+}
+
+// blockingTimeout limits timeout to the ctx deadline, 0 timeout means no limit.
+func blockingTimeout(ctx context.Context, timeout time.Duration) (time.Duration, error) {
+	err := ctx.Err()
+	if err != nil {
+		return 0, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return timeout, nil
+	}
+
+	remain := time.Until(deadline)
+	if remain <= 0 {
+		return 0, context.DeadlineExceeded
+	}
+
+	if timeout <= 0 || timeout > remain {
+		return remain, nil
+	}
+
+	return timeout, nil
+}
+
+func (k ListKey) LRange(ctx context.Context, start, stop int64) ([]string, error) {
+	startTime := time.Now()
+	values, err := MustGetRedis(ctx).LRange(ctx, k.key, start, stop).Result()
+	if err != nil {
+		log.Warn(ctx, "lrange failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int64("start", start),
+			log.Int64("stop", stop),
+			log.Duration("duration", time.Since(startTime)))
+		return nil, err
+	}
+
+	log.Debug(ctx, "lrange successfully",
+		log.String("key", k.key),
+		log.Int64("start", start),
+		log.Int64("stop", stop),
+		log.Int("count", len(values)),
+		log.Duration("duration", time.Since(startTime)))
+
+	return values, nil
+}
+
+func (k ListKey) LTrim(ctx context.Context, start, stop int64) error {
+	startTime := time.Now()
+	err := MustGetRedis(ctx).LTrim(ctx, k.key, start, stop).Err()
+	if err != nil {
+		log.Warn(ctx, "ltrim failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int64("start", start),
+			log.Int64("stop", stop),
+			log.Duration("duration", time.Since(startTime)))
+		return err
+	}
+
+	log.Debug(ctx, "ltrim successfully",
+		log.String("key", k.key),
+		log.Int64("start", start),
+		log.Int64("stop", stop),
+		log.Duration("duration", time.Since(startTime)))
+
+	return nil
+}
+
+// LIndex returns redis.Nil if index is out of range.
+func (k ListKey) LIndex(ctx context.Context, index int64) (string, error) {
+	start := time.Now()
+	value, err := MustGetRedis(ctx).LIndex(ctx, k.key, index).Result()
+	if err != nil {
+		log.Warn(ctx, "lindex failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int64("index", index),
+			log.Duration("duration", time.Since(start)))
+		return "", err
+	}
+
+	log.Debug(ctx, "lindex successfully",
+		log.String("key", k.key),
+		log.Int64("index", index),
+		log.String("value", value),
+		log.Duration("duration", time.Since(start)))
+
+	return value, nil
+}
+
+func (k ListKey) LSet(ctx context.Context, index int64, value string) error {
+	start := time.Now()
+	err := MustGetRedis(ctx).LSet(ctx, k.key, index, value).Err()
+	if err != nil {
+		log.Warn(ctx, "lset failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int64("index", index),
+			log.String("value", value),
+			log.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	log.Debug(ctx, "lset successfully",
+		log.String("key", k.key),
+		log.Int64("index", index),
+		log.String("value", value),
+		log.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+// LRem removes count occurrences of value, from the head if count is positive,
+// from the tail if negative, or all of them if 0.
+func (k ListKey) LRem(ctx context.Context, count int64, value string) (int64, error) {
+	start := time.Now()
+	removed, err := MustGetRedis(ctx).LRem(ctx, k.key, count, value).Result()
+	if err != nil {
+		log.Warn(ctx, "lrem failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int64("count", count),
+			log.String("value", value),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "lrem successfully",
+		log.String("key", k.key),
+		log.Int64("count", count),
+		log.String("value", value),
+		log.Int64("removed", removed),
+		log.Duration("duration", time.Since(start)))
+
+	return removed, nil
+}
+
+// LPos returns the index of value, it returns redis.Nil if not found.
+func (k ListKey) LPos(ctx context.Context, value string, args redis.LPosArgs) (int64, error) {
+	start := time.Now()
+	index, err := MustGetRedis(ctx).LPos(ctx, k.key, value, args).Result()
+	if err != nil {
+		log.Warn(ctx, "lpos failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("value", value),
+			log.Any("args", args),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "lpos successfully",
+		log.String("key", k.key),
+		log.String("value", value),
+		log.Int64("index", index),
+		log.Duration("duration", time.Since(start)))
+
+	return index, nil
+}
+
+func (k ListKey) LPosCount(ctx context.Context, value string, count int64, args redis.LPosArgs) ([]int64, error) {
+	start := time.Now()
+	indexes, err := MustGetRedis(ctx).LPosCount(ctx, k.key, value, count, args).Result()
+	if err != nil {
+		log.Warn(ctx, "lpos count failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("value", value),
+			log.Int64("count", count),
+			log.Any("args", args),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, "lpos count successfully",
+		log.String("key", k.key),
+		log.String("value", value),
+		log.Int64s("indexes", indexes),
+		log.Duration("duration", time.Since(start)))
+
+	return indexes, nil
+}
+
+func (k ListKey) LLen(ctx context.Context) (int64, error) {
+	start := time.Now()
+	length, err := MustGetRedis(ctx).LLen(ctx, k.key).Result()
+	if err != nil {
+		log.Warn(ctx, "llen failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "llen successfully",
+		log.String("key", k.key),
+		log.Int64("length", length),
+		log.Duration("duration", time.Since(start)))
+
+	return length, nil
+}
+
+func (k ListKey) LPushObject(ctx context.Context, objs ...interface{}) (int64, error) {
+	values, err := k.marshalObjects(ctx, objs)
+	if err != nil {
+		return 0, err
+	}
+
+	return k.LPush(ctx, values...)
+}
+
+func (k ListKey) RPushObject(ctx context.Context, objs ...interface{}) (int64, error) {
+	values, err := k.marshalObjects(ctx, objs)
+	if err != nil {
+		return 0, err
+	}
+
+	return k.RPush(ctx, values...)
+}
+
+func (k ListKey) marshalObjects(ctx context.Context, objs []interface{}) ([]string, error) {
+	values := make([]string, len(objs))
+	for index, obj := range objs {
+		buffer, err := marshalObject(obj)
+		if err != nil {
+			log.Warn(ctx, "json marshal failed",
+				log.Err(err),
+				log.String("key", k.key),
+				log.Any("obj", obj))
+			return nil, err
+		}
+
+		values[index] = string(buffer)
+	}
+
+	return values, nil
+}
+
+func (k ListKey) LPopObject(ctx context.Context, obj interface{}) error {
+	value, err := k.LPop(ctx)
+	if err != nil {
+		return err
+	}
+
+	return k.unmarshalObject(ctx, value, obj)
+}
+
+func (k ListKey) RPopObject(ctx context.Context, obj interface{}) error {
+	value, err := k.RPop(ctx)
+	if err != nil {
+		return err
+	}
+
+	return k.unmarshalObject(ctx, value, obj)
+}
+
+func (k ListKey) LIndexObject(ctx context.Context, index int64, obj interface{}) error {
+	value, err := k.LIndex(ctx, index)
+	if err != nil {
+		return err
+	}
+
+	return k.unmarshalObject(ctx, value, obj)
+}
+
+// unmarshalObject decodes value into obj, upgraded list items are not written back.
+func (k ListKey) unmarshalObject(ctx context.Context, value string, obj interface{}) error {
+	_, err := unmarshalObject(value, obj)
+	if err != nil {
+		log.Warn(ctx, "json unmarshal failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("value", value))
+		return err
+	}
+
+	return nil
+}
+
+func LRangeObjects[T any](ctx context.Context, k *ListKey, start, stop int64) ([]T, error) {
+	values, err := k.LRange(ctx, start, stop)
+	if err != nil {
+		return nil, err
+	}
+
+	objs := make([]T, len(values))
+	for index, value := range values {
+		err = k.unmarshalObject(ctx, value, &objs[index])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return objs, nil
+}
+
+type ListParameterKey struct {
+	*ParameterKey
+}
+
+func NewListParameterKey(pattern string) *ListParameterKey {
+	k := listParameterKeyPool.Get().(*ListParameterKey)
+	k.ParameterKey = NewParameterKey(pattern)
+	return k
+}
+
+func (k ListParameterKey) Param(parameters ...interface{}) *ListKey {
+	return NewListKey(fmt.Sprintf(k.pattern, parameters...))
+}
@@ -0,0 +1,230 @@
+package ro
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestListKey_PushPop(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewListKey("list:push")
+	defer key.Del(ctx)
+
+	length, err := key.LPushX(ctx, "a")
+	if err != nil || length != 0 {
+		t.Errorf("lpushx on missing list should not push, get %d, %v", length, err)
+	}
+
+	length, err = key.RPush(ctx, "b", "c", "d")
+	if err != nil || length != 3 {
+		t.Errorf("rpush failed, get %d, %v", length, err)
+	}
+
+	length, err = key.LPushX(ctx, "a")
+	if err != nil || length != 4 {
+		t.Errorf("lpushx failed, get %d, %v", length, err)
+	}
+
+	length, err = key.RPushX(ctx, "e", "f")
+	if err != nil || length != 6 {
+		t.Errorf("rpushx failed, get %d, %v", length, err)
+	}
+
+	values, err := key.LRange(ctx, 0, -1)
+	if err != nil || !reflect.DeepEqual(values, []string{"a", "b", "c", "d", "e", "f"}) {
+		t.Errorf("lrange failed, get %v, %v", values, err)
+	}
+
+	value, err := key.LPop(ctx)
+	if err != nil || value != "a" {
+		t.Errorf("lpop failed, get %s, %v", value, err)
+	}
+
+	value, err = key.RPop(ctx)
+	if err != nil || value != "f" {
+		t.Errorf("rpop failed, get %s, %v", value, err)
+	}
+
+	values, err = key.LPopCount(ctx, 2)
+	if err != nil || !reflect.DeepEqual(values, []string{"b", "c"}) {
+		t.Errorf("lpop count failed, get %v, %v", values, err)
+	}
+
+	values, err = key.RPopCount(ctx, 5)
+	if err != nil || !reflect.DeepEqual(values, []string{"e", "d"}) {
+		t.Errorf("rpop count failed, get %v, %v", values, err)
+	}
+
+	_, err = key.LPop(ctx)
+	if err != redis.Nil {
+		t.Errorf("lpop empty list should return redis.Nil, get %v", err)
+	}
+}
+
+func TestListKey_Edit(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewListKey("list:edit")
+	defer key.Del(ctx)
+
+	_, _ = key.RPush(ctx, "a", "b", "a", "c", "a", "d")
+
+	index, err := key.LPos(ctx, "a", redis.LPosArgs{Rank: 2})
+	if err != nil || index != 2 {
+		t.Errorf("lpos failed, get %d, %v", index, err)
+	}
+
+	indexes, err := key.LPosCount(ctx, "a", 0, redis.LPosArgs{})
+	if err != nil || !reflect.DeepEqual(indexes, []int64{0, 2, 4}) {
+		t.Errorf("lpos count failed, get %v, %v", indexes, err)
+	}
+
+	removed, err := key.LRem(ctx, -2, "a")
+	if err != nil || removed != 2 {
+		t.Errorf("lrem failed, get %d, %v", removed, err)
+	}
+
+	err = key.LSet(ctx, 1, "B")
+	if err != nil {
+		t.Errorf("lset failed due to %v", err)
+	}
+
+	value, err := key.LIndex(ctx, 1)
+	if err != nil || value != "B" {
+		t.Errorf("lindex failed, get %s, %v", value, err)
+	}
+
+	err = key.LTrim(ctx, 0, 1)
+	if err != nil {
+		t.Errorf("ltrim failed due to %v", err)
+	}
+
+	length, err := key.LLen(ctx)
+	if err != nil || length != 2 {
+		t.Errorf("llen failed, get %d, %v", length, err)
+	}
+}
+
+func TestListKey_Blocking(t *testing.T) {
+	ctx := context.Background()
+
+	key1 := NewListKey("list:blocking:1")
+	key2 := NewListKey("list:blocking:2")
+	destination := NewListKey("list:blocking:destination")
+	defer key1.Del(ctx)
+	defer key2.Del(ctx)
+	defer destination.Del(ctx)
+
+	_, _ = key2.RPush(ctx, "a", "b")
+
+	key, value, err := key1.BLPop(ctx, time.Second, key2)
+	if err != nil || key != "list:blocking:2" || value != "a" {
+		t.Errorf("blpop failed, get %s %s, %v", key, value, err)
+	}
+
+	key, value, err = key2.BRPop(ctx, time.Second)
+	if err != nil || key != "list:blocking:2" || value != "b" {
+		t.Errorf("brpop failed, get %s %s, %v", key, value, err)
+	}
+
+	_, _, err = key1.BLPop(ctx, time.Second, key2)
+	if err != ErrRecordNotFound {
+		t.Errorf("blpop empty lists should return ErrRecordNotFound, get %v", err)
+	}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_, _ = key1.RPush(ctx, "c")
+	}()
+
+	value, err = key1.BLMove(ctx, destination, ListLeft, ListRight, 2*time.Second)
+	if err != nil || value != "c" {
+		t.Errorf("blmove failed, get %s, %v", value, err)
+	}
+
+	value, err = destination.LMove(ctx, key1, ListRight, ListLeft)
+	if err != nil || value != "c" {
+		t.Errorf("lmove failed, get %s, %v", value, err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, _, err = key1.BLPop(canceled, time.Second)
+	if err != context.Canceled {
+		t.Errorf("blpop with canceled context should return context.Canceled, get %v", err)
+	}
+}
+
+func TestBlockingTimeout(t *testing.T) {
+	timeout, err := blockingTimeout(context.Background(), 0)
+	if err != nil || timeout != 0 {
+		t.Errorf("timeout without deadline should be kept, get %v, %v", timeout, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	timeout, err = blockingTimeout(ctx, 0)
+	if err != nil || timeout <= 0 || timeout > time.Minute {
+		t.Errorf("0 timeout should be limited to the deadline, get %v, %v", timeout, err)
+	}
+
+	timeout, err = blockingTimeout(ctx, time.Hour)
+	if err != nil || timeout > time.Minute {
+		t.Errorf("timeout should be limited to the deadline, get %v, %v", timeout, err)
+	}
+
+	timeout, err = blockingTimeout(ctx, time.Second)
+	if err != nil || timeout != time.Second {
+		t.Errorf("timeout before deadline should be kept, get %v, %v", timeout, err)
+	}
+}
+
+type listTestObject struct {
+	ID   int
+	Name string
+}
+
+func TestListKey_Objects(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewListParameterKey("list:objects:%d").Param(1)
+	defer key.Del(ctx)
+
+	_, err := key.RPushObject(ctx, &listTestObject{ID: 1, Name: "a"}, &listTestObject{ID: 2, Name: "b"})
+	if err != nil {
+		t.Fatalf("rpush object failed due to %v", err)
+	}
+
+	_, err = key.LPushObject(ctx, &listTestObject{ID: 0, Name: "z"})
+	if err != nil {
+		t.Fatalf("lpush object failed due to %v", err)
+	}
+
+	objs, err := LRangeObjects[listTestObject](ctx, key, 0, -1)
+	if err != nil || len(objs) != 3 || objs[0].Name != "z" || objs[2].ID != 2 {
+		t.Errorf("lrange objects failed, get %+v, %v", objs, err)
+	}
+
+	obj := new(listTestObject)
+	err = key.LIndexObject(ctx, 1, obj)
+	if err != nil || obj.Name != "a" {
+		t.Errorf("lindex object failed, get %+v, %v", obj, err)
+	}
+
+	err = key.RPopObject(ctx, obj)
+	if err != nil || obj.ID != 2 {
+		t.Errorf("rpop object failed, get %+v, %v", obj, err)
+	}
+
+	err = key.LPopObject(ctx, obj)
+	if err != nil || obj.ID != 0 {
+		t.Errorf("lpop object failed, get %+v, %v", obj, err)
+	}
+}
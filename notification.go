@@ -0,0 +1,253 @@
+package ro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrKeyspaceNotificationsDisabled = errors.New("keyspace notifications disabled")
+
+// KeyEvent is the event name of a keyspace notification.
+type KeyEvent string
+
+const (
+	KeyEventDel        KeyEvent = "del"
+	KeyEventExpire     KeyEvent = "expire"
+	KeyEventRenameFrom KeyEvent = "rename_from"
+	KeyEventRenameTo   KeyEvent = "rename_to"
+	KeyEventExpired    KeyEvent = "expired"
+	KeyEventEvicted    KeyEvent = "evicted"
+	KeyEventNew        KeyEvent = "new"
+	KeyEventSet        KeyEvent = "set"
+	KeyEventIncrBy     KeyEvent = "incrby"
+	KeyEventHSet       KeyEvent = "hset"
+	KeyEventHDel       KeyEvent = "hdel"
+	KeyEventLPush      KeyEvent = "lpush"
+	KeyEventRPush      KeyEvent = "rpush"
+	KeyEventSAdd       KeyEvent = "sadd"
+	KeyEventSRem       KeyEvent = "srem"
+	KeyEventZAdd       KeyEvent = "zadd"
+	KeyEventZRem       KeyEvent = "zrem"
+	KeyEventXAdd       KeyEvent = "xadd"
+)
+
+// keyEventClasses maps events to their notify-keyspace-events class flag, unknown events need all classes.
+var keyEventClasses = map[KeyEvent]rune{
+	KeyEventDel:        'g',
+	KeyEventExpire:     'g',
+	KeyEventRenameFrom: 'g',
+	KeyEventRenameTo:   'g',
+	KeyEventExpired:    'x',
+	KeyEventEvicted:    'e',
+	KeyEventNew:        'n',
+	KeyEventSet:        '$',
+	KeyEventIncrBy:     '$',
+	KeyEventHSet:       'h',
+	KeyEventHDel:       'h',
+	KeyEventLPush:      'l',
+	KeyEventRPush:      'l',
+	KeyEventSAdd:       's',
+	KeyEventSRem:       's',
+	KeyEventZAdd:       'z',
+	KeyEventZRem:       'z',
+	KeyEventXAdd:       't',
+}
+
+type KeyspaceNotification struct {
+	DB    int
+	Key   string
+	Event KeyEvent
+}
+
+type NotificationOption struct {
+	SubscribeOption
+	// Events filters the delivered events, all events if empty.
+	Events []KeyEvent
+	// Verify checks notify-keyspace-events before subscribing, ErrKeyspaceNotificationsDisabled if the required flags are missing.
+	Verify bool
+	// Enable adds the missing flags to notify-keyspace-events by CONFIG SET, which managed redis services usually refuse.
+	Enable bool
+}
+
+// SubscribeNotifications subscribes the keyspace notifications of k,
+// notifications are not propagated between nodes in cluster mode.
+func (k Key) SubscribeNotifications(ctx context.Context, option *NotificationOption) (*Subscription[KeyspaceNotification], error) {
+	return subscribeNotifications(ctx, subscribeChannel, 'K', func(db int) []string {
+		return []string{fmt.Sprintf("__keyspace@%d__:%s", db, k.key)}
+	}, option)
+}
+
+// SubscribeNotifications subscribes the keyspace notifications of all keys matching the pattern.
+func (k ParameterKey) SubscribeNotifications(ctx context.Context, option *NotificationOption) (*Subscription[KeyspaceNotification], error) {
+	return subscribeNotifications(ctx, subscribePattern, 'K', func(db int) []string {
+		return []string{fmt.Sprintf("__keyspace@%d__:%s", db, k.Match())}
+	}, option)
+}
+
+// SubscribeKeyEvents subscribes the keyevent notifications of any key, option.Events is ignored.
+func SubscribeKeyEvents(ctx context.Context, option *NotificationOption, events ...KeyEvent) (*Subscription[KeyspaceNotification], error) {
+	if len(events) == 0 {
+		return nil, ErrBadRequest
+	}
+
+	o := NotificationOption{}
+	if option != nil {
+		o = *option
+	}
+	o.Events = events
+
+	return subscribeNotifications(ctx, subscribeChannel, 'E', func(db int) []string {
+		channels := make([]string, len(events))
+		for index, event := range events {
+			channels[index] = fmt.Sprintf("__keyevent@%d__:%s", db, event)
+		}
+		return channels
+	}, &o)
+}
+
+func subscribeNotifications(ctx context.Context, mode subscribeMode, kind rune, channels func(db int) []string, option *NotificationOption) (*Subscription[KeyspaceNotification], error) {
+	o := NotificationOption{}
+	if option != nil {
+		o = *option
+	}
+
+	if o.Verify || o.Enable {
+		err := checkKeyspaceEvents(ctx, keyspaceEventFlags(kind, o.Events), o.Enable)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	events := make(map[KeyEvent]bool, len(o.Events))
+	for _, event := range o.Events {
+		events[event] = true
+	}
+
+	db := MustGetRedis(ctx).Options().DB
+	return subscribe(ctx, mode, channels(db), keyspaceNotificationDecoder(events), &o.SubscribeOption)
+}
+
+// keyspaceEventFlags returns the notify-keyspace-events flags required by the events.
+func keyspaceEventFlags(kind rune, events []KeyEvent) string {
+	flags := []rune{kind}
+	if len(events) == 0 {
+		return string(append(flags, 'A'))
+	}
+
+	for _, event := range events {
+		class, found := keyEventClasses[event]
+		if !found {
+			class = 'A'
+		}
+
+		if !strings.ContainsRune(string(flags), class) {
+			flags = append(flags, class)
+		}
+	}
+
+	return string(flags)
+}
+
+// missingKeyspaceEventFlags returns the required flags not covered by the configured ones, A is the alias of g$lshzxetd.
+func missingKeyspaceEventFlags(configured, required string) string {
+	missing := make([]rune, 0, len(required))
+	for _, flag := range required {
+		if strings.ContainsRune(configured, flag) {
+			continue
+		}
+
+		if strings.ContainsRune("g$lshzxetd", flag) && strings.ContainsRune(configured, 'A') {
+			continue
+		}
+
+		missing = append(missing, flag)
+	}
+
+	return string(missing)
+}
+
+func checkKeyspaceEvents(ctx context.Context, required string, enable bool) error {
+	start := time.Now()
+	client := MustGetRedis(ctx)
+	config, err := client.ConfigGet(ctx, "notify-keyspace-events").Result()
+	if err != nil {
+		log.Warn(ctx, "get notify-keyspace-events failed",
+			log.Err(err),
+			log.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	configured := config["notify-keyspace-events"]
+	missing := missingKeyspaceEventFlags(configured, required)
+	if missing == "" {
+		return nil
+	}
+
+	if !enable {
+		log.Warn(ctx, "keyspace notifications are not configured",
+			log.String("configured", configured),
+			log.String("required", required),
+			log.Duration("duration", time.Since(start)))
+		return ErrKeyspaceNotificationsDisabled
+	}
+
+	err = client.ConfigSet(ctx, "notify-keyspace-events", configured+missing).Err()
+	if err != nil {
+		log.Warn(ctx, "enable keyspace notifications failed",
+			log.Err(err),
+			log.String("configured", configured),
+			log.String("missing", missing),
+			log.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	log.Debug(ctx, "enable keyspace notifications successfully",
+		log.String("configured", configured),
+		log.String("missing", missing),
+		log.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+func keyspaceNotificationDecoder(events map[KeyEvent]bool) func(*redis.Message) (KeyspaceNotification, error) {
+	return func(message *redis.Message) (KeyspaceNotification, error) {
+		var notification KeyspaceNotification
+		prefix, target, found := strings.Cut(message.Channel, "__:")
+		if !found {
+			return notification, fmt.Errorf("invalid notification channel: %s", message.Channel)
+		}
+
+		var db string
+		switch {
+		case strings.HasPrefix(prefix, "__keyspace@"):
+			db = strings.TrimPrefix(prefix, "__keyspace@")
+			notification.Key = target
+			notification.Event = KeyEvent(message.Payload)
+		case strings.HasPrefix(prefix, "__keyevent@"):
+			db = strings.TrimPrefix(prefix, "__keyevent@")
+			notification.Key = message.Payload
+			notification.Event = KeyEvent(target)
+		default:
+			return notification, fmt.Errorf("invalid notification channel: %s", message.Channel)
+		}
+
+		var err error
+		notification.DB, err = strconv.Atoi(db)
+		if err != nil {
+			return notification, err
+		}
+
+		if len(events) > 0 && !events[notification.Event] {
+			return notification, errSkipMessage
+		}
+
+		return notification, nil
+	}
+}
@@ -0,0 +1,148 @@
+package ro
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func waitSubscribed(t *testing.T, ctx context.Context, channel string, pattern bool) {
+	for index := 0; index < 50; index++ {
+		var subscribers int64
+		if pattern {
+			subscribers, _ = MustGetRedis(ctx).PubSubNumPat(ctx).Result()
+		} else {
+			counts, _ := MustGetRedis(ctx).PubSubNumSub(ctx, channel).Result()
+			subscribers = counts[channel]
+		}
+
+		if subscribers > 0 {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("%s should be subscribed", channel)
+}
+
+// resetKeyspaceEvents disables keyspace notifications now and after the test.
+func resetKeyspaceEvents(t *testing.T, ctx context.Context) {
+	reset := func() {
+		err := MustGetRedis(ctx).ConfigSet(ctx, "notify-keyspace-events", "").Err()
+		if err != nil {
+			t.Fatalf("reset notify-keyspace-events failed, %v", err)
+		}
+	}
+
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestKeyspaceEventFlags(t *testing.T) {
+	flags := keyspaceEventFlags('K', nil)
+	if flags != "KA" {
+		t.Errorf("all events should require KA, get %s", flags)
+	}
+
+	flags = keyspaceEventFlags('E', []KeyEvent{KeyEventExpired, KeyEventDel, KeyEventExpire, "unknown"})
+	if flags != "ExgA" {
+		t.Errorf("flags failed, get %s", flags)
+	}
+
+	missing := missingKeyspaceEventFlags("AK", "Kxgn")
+	if missing != "n" {
+		t.Errorf("A should cover all classes except n, get %s", missing)
+	}
+}
+
+func TestKey_SubscribeNotifications(t *testing.T) {
+	ctx := context.Background()
+
+	resetKeyspaceEvents(t, ctx)
+
+	k := NewStringKey("test:notification:session")
+	defer k.Del(ctx)
+
+	option := &NotificationOption{
+		Events: []KeyEvent{KeyEventSet, KeyEventExpired},
+		Verify: true,
+	}
+	_, err := k.SubscribeNotifications(ctx, option)
+	if err != ErrKeyspaceNotificationsDisabled {
+		t.Errorf("verify should fail without notify-keyspace-events, get %v", err)
+	}
+
+	option.Enable = true
+	s, err := k.SubscribeNotifications(ctx, option)
+	if err != nil {
+		t.Fatalf("subscribe notifications failed, %v", err)
+	}
+	defer s.Close()
+	waitSubscribed(t, ctx, "__keyspace@0__:test:notification:session", false)
+
+	_ = k.Set(ctx, "alice", 50*time.Millisecond)
+
+	notification := receiveMessage(t, s.Channel())
+	if notification.Key != "test:notification:session" || notification.Event != KeyEventSet || notification.DB != 0 {
+		t.Errorf("receive set notification failed, get %+v", notification)
+	}
+
+	// the expire event is filtered out
+	notification = receiveMessage(t, s.Channel())
+	if notification.Event != KeyEventExpired {
+		t.Errorf("receive expired notification failed, get %+v", notification)
+	}
+}
+
+func TestParameterKey_SubscribeNotifications(t *testing.T) {
+	ctx := context.Background()
+
+	resetKeyspaceEvents(t, ctx)
+
+	k := NewHashSetParameterKey("test:notification:user:%d")
+	s, err := k.SubscribeNotifications(ctx, &NotificationOption{Enable: true})
+	if err != nil {
+		t.Fatalf("subscribe notifications failed, %v", err)
+	}
+	defer s.Close()
+	waitSubscribed(t, ctx, "", true)
+
+	user := k.Param(1)
+	_ = user.HSet(ctx, "name", "alice")
+	_ = user.Del(ctx)
+
+	for _, event := range []KeyEvent{KeyEventHSet, KeyEventDel} {
+		notification := receiveMessage(t, s.Channel())
+		if notification.Key != "test:notification:user:1" || notification.Event != event {
+			t.Errorf("receive %s notification failed, get %+v", event, notification)
+		}
+	}
+}
+
+func TestSubscribeKeyEvents(t *testing.T) {
+	ctx := context.Background()
+
+	resetKeyspaceEvents(t, ctx)
+
+	s, err := SubscribeKeyEvents(ctx, &NotificationOption{Enable: true}, KeyEventDel)
+	if err != nil {
+		t.Fatalf("subscribe key events failed, %v", err)
+	}
+	defer s.Close()
+	waitSubscribed(t, ctx, "__keyevent@0__:del", false)
+
+	k := NewStringKey("test:notification:deleted")
+	_ = k.Set(ctx, "value", time.Minute)
+	_ = k.Del(ctx)
+
+	notification := receiveMessage(t, s.Channel())
+	if notification.Key != "test:notification:deleted" || notification.Event != KeyEventDel {
+		t.Errorf("receive del notification failed, get %+v", notification)
+	}
+
+	_, err = SubscribeKeyEvents(ctx, nil)
+	if err != ErrBadRequest {
+		t.Errorf("subscribe without events should fail, get %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+package ro
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultPatternScanCount = 1000
+	defaultPatternBatchSize = 500
+)
+
+type PatternOption struct {
+	// ScanCount is the COUNT hint of SCAN.
+	ScanCount int64
+	// BatchSize is the max keys sent in one pipeline.
+	BatchSize int
+	// BatchInterval is the pause between batches of a node, to avoid overwhelming redis.
+	BatchInterval time.Duration
+	// DryRun only counts the matched keys.
+	DryRun bool
+	// Progress is called after every batch, one call at a time even if the masters of a cluster are scanned concurrently.
+	Progress func(PatternProgress)
+}
+
+// PatternProgress is the progress of one node.
+type PatternProgress struct {
+	// Node is the address of the scanned node.
+	Node     string
+	Scanned  int64
+	Affected int64
+}
+
+// DeleteByPattern unlinks the keys matching the pattern. If a cluster is configured by SetClusterConfig,
+// every master is scanned concurrently, otherwise the node configured by SetConfig only.
+func DeleteByPattern(ctx context.Context, match string, option *PatternOption) (int64, error) {
+	return runByPattern(ctx, "delete", match, option, func(ctx context.Context, pipe redis.Pipeliner, key string) func() int64 {
+		return pipe.Unlink(ctx, key).Val
+	})
+}
+
+func ExpireByPattern(ctx context.Context, match string, expiration time.Duration, option *PatternOption) (int64, error) {
+	return runByPattern(ctx, "expire", match, option, func(ctx context.Context, pipe redis.Pipeliner, key string) func() int64 {
+		return boolCount(pipe.Expire(ctx, key, expiration))
+	})
+}
+
+func PersistByPattern(ctx context.Context, match string, option *PatternOption) (int64, error) {
+	return runByPattern(ctx, "persist", match, option, func(ctx context.Context, pipe redis.Pipeliner, key string) func() int64 {
+		return boolCount(pipe.Persist(ctx, key))
+	})
+}
+
+func (k ParameterKey) DeleteByPattern(ctx context.Context, option *PatternOption) (int64, error) {
+	return DeleteByPattern(ctx, k.Match(), option)
+}
+
+func (k ParameterKey) ExpireByPattern(ctx context.Context, expiration time.Duration, option *PatternOption) (int64, error) {
+	return ExpireByPattern(ctx, k.Match(), expiration, option)
+}
+
+func (k ParameterKey) PersistByPattern(ctx context.Context, option *PatternOption) (int64, error) {
+	return PersistByPattern(ctx, k.Match(), option)
+}
+
+// patternCommand queues the command for the key and returns how to count the affected keys.
+type patternCommand func(ctx context.Context, pipe redis.Pipeliner, key string) func() int64
+
+func boolCount(cmd *redis.BoolCmd) func() int64 {
+	return func() int64 {
+		if cmd.Val() {
+			return 1
+		}
+
+		return 0
+	}
+}
+
+func runByPattern(ctx context.Context, operation, match string, option *PatternOption, command patternCommand) (int64, error) {
+	if option == nil {
+		option = &PatternOption{}
+	}
+
+	start := time.Now()
+	affected, err := runByPatternOnNodes(ctx, match, option, command)
+	if err != nil {
+		log.Warn(ctx, operation+" by pattern failed",
+			log.Err(err),
+			log.String("match", match),
+			log.Bool("dryRun", option.DryRun),
+			log.Int64("affected", affected),
+			log.Duration("duration", time.Since(start)))
+		return affected, err
+	}
+
+	log.Debug(ctx, operation+" by pattern successfully",
+		log.String("match", match),
+		log.Bool("dryRun", option.DryRun),
+		log.Int64("affected", affected),
+		log.Duration("duration", time.Since(start)))
+
+	return affected, nil
+}
+
+// runByPatternOnNodes runs on every master of the configured cluster, or on the configured node.
+func runByPatternOnNodes(ctx context.Context, match string, option *PatternOption, command patternCommand) (int64, error) {
+	cluster, err := GetRedisCluster(ctx)
+	if err == ErrConfigUndefined {
+		return runByPatternOnNode(ctx, MustGetRedis(ctx), match, option, command)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	o := *option
+	if option.Progress != nil {
+		var mutex sync.Mutex
+		o.Progress = func(progress PatternProgress) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			option.Progress(progress)
+		}
+	}
+
+	var affected atomic.Int64
+	err = cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		count, err := runByPatternOnNode(ctx, node, match, &o, command)
+		affected.Add(count)
+		return err
+	})
+
+	return affected.Load(), err
+}
+
+func runByPatternOnNode(ctx context.Context, node *redis.Client, match string, option *PatternOption, command patternCommand) (int64, error) {
+	scanCount := option.ScanCount
+	if scanCount <= 0 {
+		scanCount = defaultPatternScanCount
+	}
+
+	batchSize := option.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultPatternBatchSize
+	}
+
+	progress := PatternProgress{Node: node.Options().Addr}
+	var batch []string
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if !option.DryRun {
+			counts := make([]func() int64, len(batch))
+			_, err := node.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+				for index, key := range batch {
+					counts[index] = command(ctx, pipe, key)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, count := range counts {
+				progress.Affected += count()
+			}
+		} else {
+			progress.Affected += int64(len(batch))
+		}
+
+		batch = batch[:0]
+		if option.Progress != nil {
+			option.Progress(progress)
+		}
+
+		return sleepContext(ctx, option.BatchInterval)
+	}
+
+	var cursor uint64
+	for {
+		keys, next, err := node.Scan(ctx, cursor, match, scanCount).Result()
+		if err != nil {
+			return progress.Affected, err
+		}
+
+		progress.Scanned += int64(len(keys))
+		for _, key := range keys {
+			batch = append(batch, key)
+			if len(batch) >= batchSize {
+				err = flush()
+				if err != nil {
+					return progress.Affected, err
+				}
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	err := flush()
+	return progress.Affected, err
+}
+
+func sleepContext(ctx context.Context, duration time.Duration) error {
+	if duration <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
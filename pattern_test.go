@@ -0,0 +1,136 @@
+package ro
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestParameterKey_Match(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{pattern: "user:%d:cart", want: "user:*:cart"},
+		{pattern: "user:%s-%d", want: "user:*-*"},
+		{pattern: "user:%s%d", want: "user:*"},
+		{pattern: "rate:%05.2f", want: "rate:*"},
+		{pattern: "100%%:%v", want: "100%:*"},
+		{pattern: "a*b?[c]:%d", want: `a\*b\?\[c\]:*`},
+		{pattern: "a*%d", want: `a\**`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if got := NewParameterKey(tt.pattern).Match(); got != tt.want {
+				t.Errorf("ParameterKey.Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteByPattern(t *testing.T) {
+	ctx := context.Background()
+
+	pattern := NewStringParameterKey("pattern:user:%d:cart")
+	for index := 0; index < 25; index++ {
+		err := pattern.Param(index).Set(ctx, "1", 0)
+		if err != nil {
+			t.Fatalf("set value failed due to %v", err)
+		}
+	}
+
+	other := NewStringKey("pattern:user:1:profile")
+	defer other.Del(ctx)
+
+	err := other.Set(ctx, "1", 0)
+	if err != nil {
+		t.Fatalf("set value failed due to %v", err)
+	}
+
+	var batches int
+	option := &PatternOption{
+		ScanCount: 10,
+		BatchSize: 10,
+		DryRun:    true,
+		Progress: func(progress PatternProgress) {
+			batches++
+		},
+	}
+
+	count, err := pattern.DeleteByPattern(ctx, option)
+	if err != nil || count != 25 || batches != 3 {
+		t.Errorf("dry run delete by pattern failed, get %d, %d batches, %v", count, batches, err)
+	}
+
+	count, err = pattern.ExpireByPattern(ctx, time.Minute, option)
+	if err != nil || count != 25 {
+		t.Errorf("dry run expire by pattern failed, get %d, %v", count, err)
+	}
+
+	ttl, err := pattern.Param(1).TTL(ctx)
+	if err != nil || ttl >= 0 {
+		t.Errorf("dry run should not expire keys, get %v, %v", ttl, err)
+	}
+
+	option.DryRun = false
+	count, err = pattern.ExpireByPattern(ctx, time.Minute, option)
+	if err != nil || count != 25 {
+		t.Errorf("expire by pattern failed, get %d, %v", count, err)
+	}
+
+	count, err = pattern.PersistByPattern(ctx, option)
+	if err != nil || count != 25 {
+		t.Errorf("persist by pattern failed, get %d, %v", count, err)
+	}
+
+	count, err = pattern.DeleteByPattern(ctx, option)
+	if err != nil || count != 25 {
+		t.Errorf("delete by pattern failed, get %d, %v", count, err)
+	}
+
+	for index := 0; index < 25; index++ {
+		exists, err := pattern.Param(index).Exists(ctx)
+		if err != nil || exists {
+			t.Errorf("key %s should be deleted, get %v, %v", fmt.Sprintf("pattern:user:%d:cart", index), exists, err)
+		}
+	}
+
+	exists, err := other.Exists(ctx)
+	if err != nil || !exists {
+		t.Errorf("not matched key should not be deleted, get %v, %v", exists, err)
+	}
+}
+
+func TestDeleteByPattern_Cluster(t *testing.T) {
+	ctx := context.Background()
+
+	withRedisCluster(t)
+
+	pattern := NewStringParameterKey("pattern:{cluster}:%d")
+	for index := 0; index < 5; index++ {
+		err := pattern.Param(index).Set(ctx, "1", 0)
+		if err != nil {
+			t.Fatalf("set value failed due to %v", err)
+		}
+	}
+
+	nodes := make(map[string]int64)
+	count, err := pattern.DeleteByPattern(ctx, &PatternOption{
+		Progress: func(progress PatternProgress) {
+			nodes[progress.Node] = progress.Affected
+		},
+	})
+	if err != nil || count != 5 {
+		t.Errorf("delete by pattern on every master failed, get %d, %v", count, err)
+	}
+
+	if nodes["127.0.0.1:16379"] != 5 {
+		t.Errorf("progress should be reported per node, get %v", nodes)
+	}
+
+	exists, err := pattern.Param(1).Exists(ctx)
+	if err != nil || exists {
+		t.Errorf("key should be deleted, get %v, %v", exists, err)
+	}
+}
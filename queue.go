@@ -0,0 +1,461 @@
+package ro
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// QueueKeyPattern keeps all keys of a queue in the same cluster slot.
+	QueueKeyPattern = "ro:queue:{%s}"
+)
+
+var (
+	// queueLeaseScript sets the lease deadline by the server time, KEYS[1] is the lease sorted set.
+	// ARGV[1] is the member, ARGV[2] is the visibility timeout in milliseconds, ARGV[3] is 1 to only extend an existing lease.
+	queueLeaseScript = redis.NewScript(`
+local t = redis.call('TIME')
+local deadline = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000) + tonumber(ARGV[2])
+if ARGV[3] == '1' and not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], deadline, ARGV[1])
+return deadline
+`)
+
+	// queueAckScript removes the lease, the processing item and its retry counter,
+	// KEYS[1] is the lease sorted set, KEYS[2] is the processing list, KEYS[3] is the retry hash, ARGV[3] is the id.
+	queueAckScript = redis.NewScript(`
+redis.call('ZREM', KEYS[1], ARGV[1])
+local removed = redis.call('LREM', KEYS[2], 1, ARGV[2])
+if removed > 0 then
+	redis.call('HDEL', KEYS[3], ARGV[3])
+end
+return removed
+`)
+
+	// queueRequeue returns an item to the queue with its retry counter increased, or to the dead letter list once exceeded.
+	// The counter is kept in a hash by id, so the item is moved byte for byte and ack can still find it by value.
+	queueRequeue = `
+local function requeue(queue, dead, retries, raw, id, max)
+	if redis.call('HINCRBY', retries, id, 1) > max then
+		redis.call('LPUSH', dead, raw)
+		return 2
+	end
+	redis.call('RPUSH', queue, raw)
+	return 1
+end
+`
+
+	// queueNackScript KEYS are the lease sorted set, the processing list, the queue, the dead letter list and the retry hash.
+	// ARGV[1] is the lease member, ARGV[2] is the item, ARGV[3] is the max retries, ARGV[4] is the id.
+	queueNackScript = redis.NewScript(queueRequeue + `
+redis.call('ZREM', KEYS[1], ARGV[1])
+if redis.call('LREM', KEYS[2], 1, ARGV[2]) == 0 then
+	return 0
+end
+return requeue(KEYS[3], KEYS[4], KEYS[5], ARGV[2], ARGV[4], tonumber(ARGV[3]))
+`)
+
+	// queueReapScript KEYS are the lease sorted set, the worker set, the queue, the dead letter list and the retry hash.
+	// ARGV[1] is the processing list prefix, ARGV[2] is the visibility timeout in milliseconds,
+	// ARGV[3] is the max retries, ARGV[4] is the max expired leases to reap.
+	// Items moved to a processing list by a worker crashed before leasing get a lease first, so they expire later.
+	queueReapScript = redis.NewScript(queueRequeue + `
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+for _, worker in ipairs(redis.call('SMEMBERS', KEYS[2])) do
+	for _, raw in ipairs(redis.call('LRANGE', ARGV[1] .. worker, 0, -1)) do
+		local member = worker .. '\n' .. raw
+		if not redis.call('ZSCORE', KEYS[1], member) then
+			redis.call('ZADD', KEYS[1], now + tonumber(ARGV[2]), member)
+		end
+	end
+end
+
+local requeued, dead = 0, 0
+for _, member in ipairs(redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', now, 'LIMIT', 0, tonumber(ARGV[4]))) do
+	redis.call('ZREM', KEYS[1], member)
+	local index = string.find(member, '\n', 1, true)
+	local worker, raw = string.sub(member, 1, index - 1), string.sub(member, index + 1)
+	if redis.call('LREM', ARGV[1] .. worker, 1, raw) > 0 then
+		-- the item is only decoded to find its id, items pushed by others are counted by value
+		local ok, message = pcall(cjson.decode, raw)
+		local id = raw
+		if ok and type(message) == 'table' and type(message.id) == 'string' then
+			id = message.id
+		end
+		if requeue(KEYS[3], KEYS[4], KEYS[5], raw, id, tonumber(ARGV[3])) == 1 then
+			requeued = requeued + 1
+		else
+			dead = dead + 1
+		end
+	end
+end
+return {requeued, dead}
+`)
+)
+
+// ReliableQueue is a list based work queue, received items are leased and return to the queue unless acked in time.
+type ReliableQueue struct {
+	key        string
+	queue      *ListKey
+	dead       *ListKey
+	workers    *SetKey
+	leases     string
+	retries    string
+	visibility time.Duration
+	maxRetries int
+	reapLimit  int
+	registered sync.Map
+}
+
+type QueueMessage struct {
+	ID   string `json:"id"`
+	Body string `json:"body"`
+	// Retry is kept apart from the encoded item, so requeued items stay unchanged.
+	Retry int `json:"-"`
+
+	worker string
+	raw    string
+}
+
+// NewReliableQueue returns the queue named name, items received more than maxRetries+1 times go to the dead letter list.
+func NewReliableQueue(name string, visibility time.Duration, maxRetries int) *ReliableQueue {
+	key := fmt.Sprintf(QueueKeyPattern, name)
+	return &ReliableQueue{
+		key:        key,
+		queue:      NewListKey(key),
+		dead:       NewListKey(key + ":dead"),
+		workers:    NewSetKey(key + ":workers"),
+		leases:     key + ":leases",
+		retries:    key + ":retries",
+		visibility: visibility,
+		maxRetries: maxRetries,
+		reapLimit:  1000,
+	}
+}
+
+func (q *ReliableQueue) processing(worker string) *ListKey {
+	return NewListKey(q.processingPrefix() + worker)
+}
+
+func (q *ReliableQueue) processingPrefix() string {
+	return q.key + ":processing:"
+}
+
+// Push appends bodies to the queue and returns their ids.
+func (q *ReliableQueue) Push(ctx context.Context, bodies ...string) ([]string, error) {
+	ids := make([]string, len(bodies))
+	values := make([]string, len(bodies))
+	for index, body := range bodies {
+		ids[index] = randomID()
+		buffer, err := json.Marshal(&QueueMessage{ID: ids[index], Body: body})
+		if err != nil {
+			log.Warn(ctx, "json marshal failed",
+				log.Err(err),
+				log.String("key", q.key),
+				log.String("body", body))
+			return nil, err
+		}
+
+		values[index] = string(buffer)
+	}
+
+	_, err := q.queue.RPush(ctx, values...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// Receive moves the next item into the processing list of worker and leases it for the visibility timeout.
+// It returns ErrRecordNotFound when block timeout, worker names should be stable across restarts so crashed leases are reaped.
+func (q *ReliableQueue) Receive(ctx context.Context, worker string, block time.Duration) (*QueueMessage, error) {
+	_, ok := q.registered.Load(worker)
+	if !ok {
+		err := q.workers.SAdd(ctx, worker)
+		if err != nil {
+			return nil, err
+		}
+		q.registered.Store(worker, struct{}{})
+	}
+
+	raw, err := q.queue.BLMove(ctx, q.processing(worker), ListLeft, ListRight, block)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	message := &QueueMessage{worker: worker, raw: raw}
+	err = json.Unmarshal([]byte(raw), message)
+	if err != nil {
+		log.Warn(ctx, "json unmarshal failed",
+			log.Err(err),
+			log.String("key", q.key),
+			log.String("worker", worker),
+			log.String("value", raw))
+		return nil, err
+	}
+
+	err = queueLeaseScript.Run(ctx, MustGetRedis(ctx), []string{q.leases}, message.leaseMember(), q.visibility.Milliseconds(), 0).Err()
+	if err != nil {
+		log.Warn(ctx, "lease queue message failed",
+			log.Err(err),
+			log.String("key", q.key),
+			log.String("worker", worker),
+			log.String("id", message.ID),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	message.Retry, err = MustGetRedis(ctx).HGet(ctx, q.retries, message.ID).Int()
+	if err != nil && err != redis.Nil {
+		log.Warn(ctx, "get queue message retry failed",
+			log.Err(err),
+			log.String("key", q.key),
+			log.String("worker", worker),
+			log.String("id", message.ID),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, "receive queue message successfully",
+		log.String("key", q.key),
+		log.String("worker", worker),
+		log.String("id", message.ID),
+		log.Int("retry", message.Retry),
+		log.Duration("duration", time.Since(start)))
+
+	return message, nil
+}
+
+func (m QueueMessage) leaseMember() string {
+	return m.worker + "\n" + m.raw
+}
+
+// Ack removes the message, it returns false if the lease has been reaped.
+func (q *ReliableQueue) Ack(ctx context.Context, message *QueueMessage) (bool, error) {
+	start := time.Now()
+	removed, err := queueAckScript.Run(ctx, MustGetRedis(ctx), []string{q.leases, q.processing(message.worker).key, q.retries},
+		message.leaseMember(), message.raw, message.ID).Int64()
+	if err != nil {
+		log.Warn(ctx, "ack queue message failed",
+			log.Err(err),
+			log.String("key", q.key),
+			log.String("worker", message.worker),
+			log.String("id", message.ID),
+			log.Duration("duration", time.Since(start)))
+		return false, err
+	}
+
+	log.Debug(ctx, "ack queue message successfully",
+		log.String("key", q.key),
+		log.String("worker", message.worker),
+		log.String("id", message.ID),
+		log.Bool("acked", removed > 0),
+		log.Duration("duration", time.Since(start)))
+
+	return removed > 0, nil
+}
+
+// Nack returns the message to the queue now, or to the dead letter list if it has been retried too many times.
+func (q *ReliableQueue) Nack(ctx context.Context, message *QueueMessage) error {
+	start := time.Now()
+	result, err := queueNackScript.Run(ctx, MustGetRedis(ctx),
+		[]string{q.leases, q.processing(message.worker).key, q.queue.key, q.dead.key, q.retries},
+		message.leaseMember(), message.raw, q.maxRetries, message.ID).Int64()
+	if err != nil {
+		log.Warn(ctx, "nack queue message failed",
+			log.Err(err),
+			log.String("key", q.key),
+			log.String("worker", message.worker),
+			log.String("id", message.ID),
+			log.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	log.Debug(ctx, "nack queue message successfully",
+		log.String("key", q.key),
+		log.String("worker", message.worker),
+		log.String("id", message.ID),
+		log.Bool("dead", result == 2),
+		log.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+// Extend renews the lease of a message in process, it returns false if the lease has been reaped.
+func (q *ReliableQueue) Extend(ctx context.Context, message *QueueMessage, visibility time.Duration) (bool, error) {
+	start := time.Now()
+	deadline, err := queueLeaseScript.Run(ctx, MustGetRedis(ctx), []string{q.leases}, message.leaseMember(), visibility.Milliseconds(), 1).Int64()
+	if err != nil {
+		log.Warn(ctx, "extend queue message lease failed",
+			log.Err(err),
+			log.String("key", q.key),
+			log.String("worker", message.worker),
+			log.String("id", message.ID),
+			log.Duration("duration", time.Since(start)))
+		return false, err
+	}
+
+	log.Debug(ctx, "extend queue message lease successfully",
+		log.String("key", q.key),
+		log.String("worker", message.worker),
+		log.String("id", message.ID),
+		log.Bool("extended", deadline > 0),
+		log.Duration("duration", time.Since(start)))
+
+	return deadline > 0, nil
+}
+
+// Reap returns the items of expired leases to the queue, it returns how many are requeued and dead lettered.
+func (q *ReliableQueue) Reap(ctx context.Context) (int64, int64, error) {
+	start := time.Now()
+	result, err := queueReapScript.Run(ctx, MustGetRedis(ctx),
+		[]string{q.leases, q.workers.key, q.queue.key, q.dead.key, q.retries},
+		q.processingPrefix(), q.visibility.Milliseconds(), q.maxRetries, q.reapLimit).Int64Slice()
+	if err == nil && len(result) != 2 {
+		err = ErrInvalidResultCount
+	}
+	if err != nil {
+		log.Warn(ctx, "reap queue failed",
+			log.Err(err),
+			log.String("key", q.key),
+			log.Duration("duration", time.Since(start)))
+		return 0, 0, err
+	}
+
+	log.Debug(ctx, "reap queue successfully",
+		log.String("key", q.key),
+		log.Int64("requeued", result[0]),
+		log.Int64("dead", result[1]),
+		log.Duration("duration", time.Since(start)))
+
+	return result[0], result[1], nil
+}
+
+func (q *ReliableQueue) Len(ctx context.Context) (int64, error) {
+	return q.queue.LLen(ctx)
+}
+
+// DeadLetters returns the dead lettered messages, the latest first, with the retry counters kept for them.
+func (q *ReliableQueue) DeadLetters(ctx context.Context, start, stop int64) ([]*QueueMessage, error) {
+	values, err := q.dead.LRange(ctx, start, stop)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	messages := make([]*QueueMessage, len(values))
+	ids := make([]string, len(values))
+	for index, value := range values {
+		messages[index] = &QueueMessage{raw: value}
+		err = json.Unmarshal([]byte(value), messages[index])
+		if err != nil {
+			log.Warn(ctx, "json unmarshal failed",
+				log.Err(err),
+				log.String("key", q.dead.key),
+				log.String("value", value))
+			return nil, err
+		}
+		ids[index] = messages[index].ID
+	}
+
+	retries, err := MustGetRedis(ctx).HMGet(ctx, q.retries, ids...).Result()
+	if err != nil {
+		log.Warn(ctx, "get dead letter retries failed",
+			log.Err(err),
+			log.String("key", q.dead.key),
+			log.Strings("ids", ids))
+		return nil, err
+	}
+
+	for index, retry := range retries {
+		if text, ok := retry.(string); ok {
+			messages[index].Retry, _ = strconv.Atoi(text)
+		}
+	}
+
+	return messages, nil
+}
+
+// Run starts concurrency workers named worker-0, worker-1... and a reaper, until ctx is done.
+// Messages are acked if handler returns nil, or nacked otherwise.
+func (q *ReliableQueue) Run(ctx context.Context, worker string, concurrency int, handler func(context.Context, *QueueMessage) error) error {
+	var wg sync.WaitGroup
+	for index := 0; index < concurrency; index++ {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			q.work(ctx, name, handler)
+		}(fmt.Sprintf("%s-%d", worker, index))
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		q.reap(ctx)
+	}()
+
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (q *ReliableQueue) work(ctx context.Context, worker string, handler func(context.Context, *QueueMessage) error) {
+	for ctx.Err() == nil {
+		message, err := q.Receive(ctx, worker, time.Second)
+		if err == ErrRecordNotFound {
+			continue
+		}
+
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Warn(ctx, "receive queue message failed", log.Err(err), log.String("key", q.key), log.String("worker", worker))
+				_ = sleepContext(ctx, time.Second)
+			}
+			continue
+		}
+
+		// the message is settled even if ctx is done during handling
+		settleCtx := context.WithoutCancel(ctx)
+		err = handler(ctx, message)
+		if err != nil {
+			log.Warn(ctx, "handle queue message failed", log.Err(err), log.String("key", q.key), log.String("id", message.ID))
+			_ = q.Nack(settleCtx, message)
+			continue
+		}
+
+		_, _ = q.Ack(settleCtx, message)
+	}
+}
+
+func (q *ReliableQueue) reap(ctx context.Context) {
+	interval := q.visibility / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _, _ = q.Reap(ctx)
+		}
+	}
+}
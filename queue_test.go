@@ -0,0 +1,207 @@
+package ro
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func deleteQueue(ctx context.Context, q *ReliableQueue, workers ...string) {
+	_ = q.queue.Del(ctx)
+	_ = q.dead.Del(ctx)
+	_ = q.workers.Del(ctx)
+	_ = NewKey(q.leases).Del(ctx)
+	_ = NewKey(q.retries).Del(ctx)
+	for _, worker := range workers {
+		_ = q.processing(worker).Del(ctx)
+	}
+}
+
+func TestReliableQueue_Lease(t *testing.T) {
+	ctx := context.Background()
+
+	q := NewReliableQueue("test:lease", 200*time.Millisecond, 1)
+	defer deleteQueue(ctx, q, "w1")
+
+	ids, err := q.Push(ctx, "a", "b")
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("push failed, get %v, %v", ids, err)
+	}
+
+	message, err := q.Receive(ctx, "w1", time.Second)
+	if err != nil || message.Body != "a" || message.ID != ids[0] {
+		t.Fatalf("receive failed, get %+v, %v", message, err)
+	}
+
+	acked, err := q.Ack(ctx, message)
+	if err != nil || !acked {
+		t.Errorf("ack failed, get %v, %v", acked, err)
+	}
+
+	message, err = q.Receive(ctx, "w1", time.Second)
+	if err != nil || message.Body != "b" {
+		t.Fatalf("receive failed, get %+v, %v", message, err)
+	}
+
+	extended, err := q.Extend(ctx, message, 200*time.Millisecond)
+	if err != nil || !extended {
+		t.Errorf("extend failed, get %v, %v", extended, err)
+	}
+
+	requeued, dead, err := q.Reap(ctx)
+	if err != nil || requeued != 0 || dead != 0 {
+		t.Errorf("reap should skip active leases, get %d %d, %v", requeued, dead, err)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+
+	requeued, dead, err = q.Reap(ctx)
+	if err != nil || requeued != 1 || dead != 0 {
+		t.Errorf("reap should requeue expired leases, get %d %d, %v", requeued, dead, err)
+	}
+
+	acked, err = q.Ack(ctx, message)
+	if err != nil || acked {
+		t.Errorf("ack reaped message should fail, get %v, %v", acked, err)
+	}
+
+	message, err = q.Receive(ctx, "w1", time.Second)
+	if err != nil || message.Body != "b" || message.Retry != 1 {
+		t.Fatalf("receive requeued message failed, get %+v, %v", message, err)
+	}
+
+	err = q.Nack(ctx, message)
+	if err != nil {
+		t.Errorf("nack failed due to %v", err)
+	}
+
+	length, err := q.Len(ctx)
+	if err != nil || length != 0 {
+		t.Errorf("message exceeded retries should not be requeued, get %d, %v", length, err)
+	}
+
+	messages, err := q.DeadLetters(ctx, 0, -1)
+	if err != nil || len(messages) != 1 || messages[0].ID != ids[1] || messages[0].Retry != 2 {
+		t.Errorf("dead letters failed, get %+v, %v", messages, err)
+	}
+
+	_, err = q.Receive(ctx, "w1", time.Second)
+	if err != ErrRecordNotFound {
+		t.Errorf("receive empty queue should return ErrRecordNotFound, get %v", err)
+	}
+}
+
+func TestReliableQueue_NackKeepsItem(t *testing.T) {
+	ctx := context.Background()
+
+	q := NewReliableQueue("test:nack", time.Second, 3)
+	defer deleteQueue(ctx, q, "w1")
+
+	// re-encoding the item with cjson would reorder its fields and escape the slash
+	_, err := q.Push(ctx, `{"b":[],"a":12345678901234567890}/`)
+	if err != nil {
+		t.Fatalf("push failed due to %v", err)
+	}
+
+	pushed, _ := q.queue.LRange(ctx, 0, -1)
+
+	message, err := q.Receive(ctx, "w1", time.Second)
+	if err != nil {
+		t.Fatalf("receive failed due to %v", err)
+	}
+
+	err = q.Nack(ctx, message)
+	if err != nil {
+		t.Fatalf("nack failed due to %v", err)
+	}
+
+	requeued, _ := q.queue.LRange(ctx, 0, -1)
+	if len(pushed) != 1 || len(requeued) != 1 || requeued[0] != pushed[0] {
+		t.Errorf("requeued item should be unchanged, get %v, want %v", requeued, pushed)
+	}
+
+	message, err = q.Receive(ctx, "w1", time.Second)
+	if err != nil || message.Retry != 1 || message.Body != `{"b":[],"a":12345678901234567890}/` {
+		t.Fatalf("receive requeued message failed, get %+v, %v", message, err)
+	}
+
+	acked, err := q.Ack(ctx, message)
+	if err != nil || !acked {
+		t.Errorf("ack requeued message failed, get %v, %v", acked, err)
+	}
+
+	exists, err := NewHashSetKey(q.retries).HExists(ctx, message.ID)
+	if err != nil || exists {
+		t.Errorf("ack should remove the retry counter, get %v, %v", exists, err)
+	}
+}
+
+func TestReliableQueue_ReapUnleased(t *testing.T) {
+	ctx := context.Background()
+
+	q := NewReliableQueue("test:unleased", 200*time.Millisecond, 3)
+	defer deleteQueue(ctx, q, "w1")
+
+	_, err := q.Push(ctx, "a")
+	if err != nil {
+		t.Fatalf("push failed due to %v", err)
+	}
+
+	// a worker crashed between moving the item and leasing it
+	_ = q.workers.SAdd(ctx, "w1")
+	_, err = q.queue.LMove(ctx, q.processing("w1"), ListLeft, ListRight)
+	if err != nil {
+		t.Fatalf("lmove failed due to %v", err)
+	}
+
+	requeued, _, err := q.Reap(ctx)
+	if err != nil || requeued != 0 {
+		t.Errorf("reap should lease unleased items first, get %d, %v", requeued, err)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+
+	requeued, _, err = q.Reap(ctx)
+	if err != nil || requeued != 1 {
+		t.Errorf("reap should requeue unleased items later, get %d, %v", requeued, err)
+	}
+}
+
+func TestReliableQueue_Run(t *testing.T) {
+	ctx := context.Background()
+
+	q := NewReliableQueue("test:run", time.Second, 0)
+	defer deleteQueue(ctx, q, "w-0", "w-1", "w-2")
+
+	_, err := q.Push(ctx, "1", "2", "3", "4", "fail")
+	if err != nil {
+		t.Fatalf("push failed due to %v", err)
+	}
+
+	var handled int64
+	runCtx, cancel := context.WithTimeout(ctx, 1500*time.Millisecond)
+	defer cancel()
+
+	err = q.Run(runCtx, "w", 3, func(ctx context.Context, message *QueueMessage) error {
+		if message.Body == "fail" {
+			return errors.New("fail")
+		}
+
+		atomic.AddInt64(&handled, 1)
+		return nil
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("run should return when ctx is done, get %v", err)
+	}
+
+	if handled != 4 {
+		t.Errorf("run should handle all messages, get %d", handled)
+	}
+
+	messages, err := q.DeadLetters(ctx, 0, -1)
+	if err != nil || len(messages) != 1 || messages[0].Body != "fail" {
+		t.Errorf("failed message should be dead lettered, get %+v, %v", messages, err)
+	}
+}
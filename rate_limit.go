@@ -0,0 +1,245 @@
+package ro
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// KEYS[1] is the sorted set of admitted requests scored by admission time in milliseconds.
+	// ARGV[1] limit, ARGV[2] window in milliseconds, ARGV[3] n, ARGV[4] nonce.
+	slidingWindowScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+if count + n <= limit then
+	for index = 1, n do
+		redis.call('ZADD', KEYS[1], now, ARGV[4] .. ':' .. index)
+	end
+	redis.call('PEXPIRE', KEYS[1], window)
+	return {1, limit - count - n, 0}
+end
+
+local remaining = math.max(limit - count, 0)
+if n > limit then
+	return {0, remaining, -1}
+end
+
+local index = count + n - limit - 1
+local oldest = redis.call('ZRANGE', KEYS[1], index, index, 'WITHSCORES')
+return {0, remaining, math.max(tonumber(oldest[2]) + window - now, 1)}
+`)
+
+	// KEYS[1] is the hash holding the bucket state.
+	// ARGV[1] burst, ARGV[2] milliseconds per token, ARGV[3] n.
+	tokenBucketScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local burst = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(now - ts, 0) / interval)
+
+local allowed = 0
+local retry = 0
+if tokens >= n then
+	tokens = tokens - n
+	allowed = 1
+elseif n > burst then
+	retry = -1
+else
+	retry = math.ceil((n - tokens) * interval)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tokens, 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) * interval) + 1)
+return {allowed, math.floor(tokens), retry}
+`)
+
+	// KEYS[1] is the string holding the theoretical arrival time in milliseconds.
+	// ARGV[1] emission interval in milliseconds, ARGV[2] burst tolerance in milliseconds, ARGV[3] n.
+	gcraScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local interval = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if tat == nil or tat < now then
+	tat = now
+end
+
+local increment = interval * n
+local newTat = tat + increment
+local diff = now - (newTat - tolerance)
+if diff < 0 then
+	local retry = math.ceil(-diff)
+	if increment > tolerance then
+		retry = -1
+	end
+	local remaining = math.max(math.floor((now - (tat - tolerance)) / interval), 0)
+	return {0, remaining, retry}
+end
+
+redis.call('SET', KEYS[1], newTat, 'PX', math.ceil(newTat - now))
+return {1, math.floor(diff / interval), 0}
+`)
+)
+
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int64
+	// RetryAfter is zero when allowed and negative when n can never be allowed.
+	RetryAfter time.Duration
+}
+
+type RateLimiter interface {
+	Allow(ctx context.Context, key *Key, n int64) (*RateLimitResult, error)
+}
+
+type SlidingWindowLimiter struct {
+	limit  int64
+	window time.Duration
+}
+
+func NewSlidingWindowLimiter(limit int64, window time.Duration) *SlidingWindowLimiter {
+	return &SlidingWindowLimiter{
+		limit:  limit,
+		window: window,
+	}
+}
+
+func (l SlidingWindowLimiter) Allow(ctx context.Context, key *Key, n int64) (*RateLimitResult, error) {
+	return runRateLimitScript(ctx, "sliding window", slidingWindowScript, key, l.limit, l.window.Milliseconds(), n, randomID())
+}
+
+type TokenBucketLimiter struct {
+	burst    int64
+	interval float64
+}
+
+func NewTokenBucketLimiter(limit int64, period time.Duration, burst int64) *TokenBucketLimiter {
+	return &TokenBucketLimiter{
+		burst:    burst,
+		interval: float64(period.Milliseconds()) / float64(limit),
+	}
+}
+
+func (l TokenBucketLimiter) Allow(ctx context.Context, key *Key, n int64) (*RateLimitResult, error) {
+	return runRateLimitScript(ctx, "token bucket", tokenBucketScript, key, l.burst, l.interval, n)
+}
+
+type GCRALimiter struct {
+	interval  float64
+	tolerance float64
+}
+
+func NewGCRALimiter(limit int64, period time.Duration, burst int64) *GCRALimiter {
+	interval := float64(period.Milliseconds()) / float64(limit)
+	return &GCRALimiter{
+		interval:  interval,
+		tolerance: interval * float64(burst),
+	}
+}
+
+func (l GCRALimiter) Allow(ctx context.Context, key *Key, n int64) (*RateLimitResult, error) {
+	return runRateLimitScript(ctx, "gcra", gcraScript, key, l.interval, l.tolerance, n)
+}
+
+func runRateLimitScript(ctx context.Context, algorithm string, script *redis.Script, key *Key, args ...interface{}) (*RateLimitResult, error) {
+	start := time.Now()
+	values, err := script.Run(ctx, MustGetRedis(ctx), []string{key.key}, args...).Int64Slice()
+	if err != nil {
+		log.Warn(ctx, "rate limit failed",
+			log.Err(err),
+			log.String("algorithm", algorithm),
+			log.String("key", key.key),
+			log.Any("args", args),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	if len(values) != 3 {
+		log.Warn(ctx, "invalid rate limit result",
+			log.String("algorithm", algorithm),
+			log.String("key", key.key),
+			log.Int64s("values", values),
+			log.Duration("duration", time.Since(start)))
+		return nil, ErrInvalidResultCount
+	}
+
+	result := &RateLimitResult{
+		Allowed:    values[0] == 1,
+		Remaining:  values[1],
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+	}
+
+	log.Debug(ctx, "rate limit successfully",
+		log.String("algorithm", algorithm),
+		log.String("key", key.key),
+		log.Any("args", args),
+		log.Any("result", result),
+		log.Duration("duration", time.Since(start)))
+
+	return result, nil
+}
+
+func RateLimitMiddleware(limiter RateLimiter, keyFunc func(*http.Request) *Key) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := keyFunc(r)
+			if key == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			result, err := limiter.Allow(r.Context(), key, 1)
+			if err != nil {
+				// fail open, an unavailable redis should not take the service down
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+			if result.Allowed {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if result.RetryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(result.RetryAfter.Seconds())), 10))
+			}
+
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		})
+	}
+}
+
+func randomID() string {
+	buffer := make([]byte, 8)
+	_, _ = rand.Read(buffer)
+	return hex.EncodeToString(buffer)
+}
@@ -0,0 +1,98 @@
+package ro
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		limiter RateLimiter
+		key     *StringKey
+	}{
+		{
+			name:    "sliding window",
+			limiter: NewSlidingWindowLimiter(3, time.Minute),
+			key:     NewStringKey("ratelimit:sliding"),
+		},
+		{
+			name:    "token bucket",
+			limiter: NewTokenBucketLimiter(3, time.Minute, 3),
+			key:     NewStringKey("ratelimit:bucket"),
+		},
+		{
+			name:    "gcra",
+			limiter: NewGCRALimiter(3, time.Minute, 3),
+			key:     NewStringKey("ratelimit:gcra"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.key.Del(ctx)
+
+			for index := int64(0); index < 3; index++ {
+				result, err := tt.limiter.Allow(ctx, tt.key.Key, 1)
+				if err != nil {
+					t.Fatalf("allow failed due to %v", err)
+				}
+
+				if !result.Allowed {
+					t.Errorf("request %d should be allowed", index)
+				}
+
+				if result.Remaining != 2-index {
+					t.Errorf("get unexpect remaining, want %d, get %d", 2-index, result.Remaining)
+				}
+			}
+
+			result, err := tt.limiter.Allow(ctx, tt.key.Key, 1)
+			if err != nil {
+				t.Fatalf("allow failed due to %v", err)
+			}
+
+			if result.Allowed {
+				t.Errorf("request over limit should not be allowed")
+			}
+
+			if result.RetryAfter <= 0 || result.RetryAfter > time.Minute {
+				t.Errorf("get unexpect retry after %v", result.RetryAfter)
+			}
+
+			result, err = tt.limiter.Allow(ctx, tt.key.Key, 4)
+			if err != nil {
+				t.Fatalf("allow failed due to %v", err)
+			}
+
+			if result.Allowed || result.RetryAfter >= 0 {
+				t.Errorf("request over burst should never be allowed, get %+v", result)
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewStringKey("ratelimit:middleware")
+	defer key.Del(ctx)
+
+	handler := RateLimitMiddleware(NewGCRALimiter(1, time.Minute, 1), func(r *http.Request) *Key {
+		return key.Key
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for _, want := range []int{http.StatusNoContent, http.StatusTooManyRequests} {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		if recorder.Code != want {
+			t.Errorf("get unexpect status, want %d, get %d", want, recorder.Code)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package ro
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
+)
+
+// temporaryKeyTTL removes a temporary key left behind by a client died before the final RENAME.
+const temporaryKeyTTL = time.Hour
+
+// ReplaceSet swaps members in as the whole set, readers see either the old or the new members.
+// Empty members delete the set.
+func (k SetKey) ReplaceSet(ctx context.Context, members []string, expiration time.Duration) error {
+	return replaceKey(ctx, "replace set", k.key, len(members), expiration, func(ctx context.Context, temporary string) error {
+		_, err := chunkedMembers(ctx, "set members", temporary, members, func(ctx context.Context, pipe redis.Pipeliner, members []string) *redis.IntCmd {
+			cmd := pipe.SAdd(ctx, temporary, stringsToInterfaces(members)...)
+			pipe.PExpire(ctx, temporary, temporaryKeyTTL)
+			return cmd
+		})
+		return err
+	})
+}
+
+// ReplaceHash swaps values in as the whole hash, readers see either the old or the new values.
+// Empty values delete the hash.
+func (k HashSetKey) ReplaceHash(ctx context.Context, values map[string]string, expiration time.Duration) error {
+	return replaceKey(ctx, "replace hash", k.key, len(values), expiration, func(ctx context.Context, temporary string) error {
+		fields := make([]string, 0, len(values))
+		for field := range values {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		_, err := chunkedMembers(ctx, "set values", temporary, fields, func(ctx context.Context, pipe redis.Pipeliner, fields []string) *redis.IntCmd {
+			pairs := make([]interface{}, 0, len(fields)*2)
+			for _, field := range fields {
+				pairs = append(pairs, field, values[field])
+			}
+			cmd := pipe.HSet(ctx, temporary, pairs...)
+			pipe.PExpire(ctx, temporary, temporaryKeyTTL)
+			return cmd
+		})
+		return err
+	})
+}
+
+// replaceKey writes the temporary key with a safety ttl of temporaryKeyTTL in every pipeline,
+// then sets the expiration or persists it and renames it to key.
+func replaceKey(ctx context.Context, operation, key string, total int, expiration time.Duration, write func(ctx context.Context, temporary string) error) error {
+	start := time.Now()
+	if total == 0 {
+		err := MustGetRedis(ctx).Del(ctx, key).Err()
+		if err != nil {
+			log.Warn(ctx, operation+" failed",
+				log.Err(err),
+				log.String("key", key),
+				log.Duration("duration", time.Since(start)))
+			return err
+		}
+
+		log.Debug(ctx, operation+" successfully",
+			log.String("key", key),
+			log.Int("total", total),
+			log.Duration("duration", time.Since(start)))
+		return nil
+	}
+
+	temporary := temporaryKey(key)
+	err := write(ctx, temporary)
+	if err == nil {
+		err = ctx.Err()
+	}
+
+	if err == nil {
+		_, err = MustGetRedis(ctx).TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if expiration > 0 {
+				pipe.PExpire(ctx, temporary, expiration)
+			} else {
+				pipe.Persist(ctx, temporary)
+			}
+			pipe.Rename(ctx, temporary, key)
+			return nil
+		})
+	}
+
+	if err != nil {
+		// the temporary key must be removed even if ctx has been canceled
+		cleanupErr := MustGetRedis(ctx).Del(context.WithoutCancel(ctx), temporary).Err()
+		log.Warn(ctx, operation+" failed",
+			log.Err(err),
+			log.String("key", key),
+			log.String("temporary", temporary),
+			log.Int("total", total),
+			log.Any("cleanupErr", cleanupErr),
+			log.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	log.Debug(ctx, operation+" successfully",
+		log.String("key", key),
+		log.Int("total", total),
+		log.Duration("expiration", expiration),
+		log.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+// temporaryKey returns a unique key in the same cluster slot as key.
+func temporaryKey(key string) string {
+	suffix := ":ro:tmp:" + randomID()
+	if hasHashTag(key) {
+		return key + suffix
+	}
+
+	// keys with an unpaired '}' can not be wrapped into the same slot, which only matters on cluster.
+	return "{" + key + "}" + suffix
+}
+
+// hasHashTag reports whether only part of key is hashed to find its cluster slot.
+func hasHashTag(key string) bool {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return false
+	}
+
+	end := strings.IndexByte(key[start+1:], '}')
+	return end > 0
+}
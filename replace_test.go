@@ -0,0 +1,106 @@
+package ro
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestTemporaryKey(t *testing.T) {
+	tests := []struct {
+		key    string
+		prefix string
+	}{
+		{key: "audience", prefix: "{audience}:ro:tmp:"},
+		{key: "audience:{1}:members", prefix: "audience:{1}:members:ro:tmp:"},
+		{key: "audience:{}:members", prefix: "{audience:{}:members}:ro:tmp:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got := temporaryKey(tt.key)
+			if len(got) <= len(tt.prefix) || got[:len(tt.prefix)] != tt.prefix {
+				t.Errorf("temporaryKey() = %v, want prefix %v", got, tt.prefix)
+			}
+		})
+	}
+}
+
+func TestSetKey_ReplaceSet(t *testing.T) {
+	ctx := context.Background()
+	withChunkOption(t, ChunkOption{ChunkSize: 2})
+
+	key := NewSetKey("replace:set")
+	defer key.Del(ctx)
+
+	_ = key.SAdd(ctx, "a", "b", "c")
+
+	err := key.ReplaceSet(ctx, []string{"c", "d", "e", "f", "g"}, time.Minute)
+	if err != nil {
+		t.Fatalf("replace set failed due to %v", err)
+	}
+
+	members, err := key.SMembers(ctx)
+	sort.Strings(members)
+	if err != nil || !reflect.DeepEqual(members, []string{"c", "d", "e", "f", "g"}) {
+		t.Errorf("replace set should swap members, get %v, %v", members, err)
+	}
+
+	ttl, err := key.TTL(ctx)
+	if err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("replace set should set ttl, get %v, %v", ttl, err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	err = key.ReplaceSet(canceled, []string{"x"}, 0)
+	if err == nil {
+		t.Errorf("replace set with canceled context should fail")
+	}
+
+	count, err := key.SCard(ctx)
+	if err != nil || count != 5 {
+		t.Errorf("failed replace should keep the live set, get %d, %v", count, err)
+	}
+
+	it := ScanKeys(0, "*:ro:tmp:*", 100, "")
+	for it.Next(ctx) {
+		t.Errorf("temporary key %s should be removed", it.Val())
+	}
+
+	err = key.ReplaceSet(ctx, nil, 0)
+	if err != nil {
+		t.Fatalf("replace set with empty members failed due to %v", err)
+	}
+
+	exists, err := key.Exists(ctx)
+	if err != nil || exists {
+		t.Errorf("replace with empty members should delete the set, get %v, %v", exists, err)
+	}
+}
+
+func TestHashSetKey_ReplaceHash(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewHashSetKey("replace:{hash}")
+	defer key.Del(ctx)
+
+	_ = key.HSet(ctx, "a", "1")
+
+	err := key.ReplaceHash(ctx, map[string]string{"b": "2", "c": "3"}, 0)
+	if err != nil {
+		t.Fatalf("replace hash failed due to %v", err)
+	}
+
+	values, err := key.HGetAll(ctx)
+	if err != nil || !reflect.DeepEqual(values, map[string]string{"b": "2", "c": "3"}) {
+		t.Errorf("replace hash should swap values, get %v, %v", values, err)
+	}
+
+	ttl, err := key.TTL(ctx)
+	if err != nil || ttl >= 0 {
+		t.Errorf("replace hash without expiration should be persistent, get %v, %v", ttl, err)
+	}
+}
@@ -10,9 +10,11 @@ import (
 )
 
 var (
-	globalRedisClient *redis.Client
-	globalMutex       sync.RWMutex
-	globalOption      *redis.Options
+	globalRedisClient   *redis.Client
+	globalClusterClient *redis.ClusterClient
+	globalMutex         sync.RWMutex
+	globalOption        *redis.Options
+	globalClusterOption *redis.ClusterOptions
 )
 
 func MustGetRedis(ctx context.Context) *redis.Client {
@@ -54,3 +56,36 @@ func GetRedis(ctx context.Context) (*redis.Client, error) {
 func SetConfig(c *redis.Options) {
 	globalOption = c
 }
+
+// GetRedisCluster returns the client of the cluster configured by SetClusterConfig, ErrConfigUndefined if not configured.
+func GetRedisCluster(ctx context.Context) (*redis.ClusterClient, error) {
+	globalMutex.Lock()
+	defer globalMutex.Unlock()
+
+	if globalClusterClient != nil {
+		return globalClusterClient, nil
+	}
+
+	if globalClusterOption == nil {
+		return nil, ErrConfigUndefined
+	}
+
+	client := redis.NewClusterClient(globalClusterOption)
+
+	err := client.Ping(ctx).Err()
+	if err != nil {
+		log.Error(ctx, "Connect to redis cluster failed", log.Err(err))
+		return nil, err
+	}
+
+	log.Debug(ctx, "connect to redis cluster successfully", log.Strings("addrs", globalClusterOption.Addrs))
+
+	globalClusterClient = client
+	return globalClusterClient, nil
+}
+
+// SetClusterConfig enables the cluster aware operations: the pattern operations scan every master
+// and the shard channels are routed by slot. The other operations keep using the client of SetConfig.
+func SetClusterConfig(c *redis.ClusterOptions) {
+	globalClusterOption = c
+}
@@ -26,3 +26,18 @@ func TestMustGetRedis(t *testing.T) {
 		t.Error("MustGetRedis() get nil")
 	}
 }
+
+// withRedisCluster configures the test server as a single master cluster until the test ends.
+func withRedisCluster(t *testing.T) {
+	SetClusterConfig(&redis.ClusterOptions{Addrs: []string{"127.0.0.1:16379"}})
+	t.Cleanup(func() {
+		globalMutex.Lock()
+		defer globalMutex.Unlock()
+
+		if globalClusterClient != nil {
+			_ = globalClusterClient.Close()
+		}
+		globalClusterClient = nil
+		globalClusterOption = nil
+	})
+}
@@ -0,0 +1,207 @@
+package ro
+
+import (
+	"context"
+	"time"
+
+	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	KeyTypeString = "string"
+	KeyTypeHash   = "hash"
+	KeyTypeSet    = "set"
+	KeyTypeStream = "stream"
+	KeyTypeList   = "list"
+	KeyTypeZSet   = "zset"
+)
+
+type scanFunc func(ctx context.Context, cursor uint64) ([]string, uint64, error)
+
+// ScanIterator walks a SCAN family command page by page.
+// Items of a page are step strings long, e.g. field and value for HSCAN.
+type ScanIterator struct {
+	scan    scanFunc
+	step    int
+	cursor  uint64
+	next    uint64
+	started bool
+	page    []string
+	index   int
+	err     error
+}
+
+func newScanIterator(cursor uint64, step int, scan scanFunc) *ScanIterator {
+	return &ScanIterator{
+		scan:   scan,
+		step:   step,
+		cursor: cursor,
+		next:   cursor,
+		index:  -step,
+	}
+}
+
+func (it *ScanIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+
+	it.index += it.step
+	for it.index+it.step > len(it.page) {
+		if it.started && it.next == 0 {
+			return false
+		}
+
+		err := ctx.Err()
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.cursor = it.next
+		it.page, it.next, err = it.scan(ctx, it.cursor)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.started = true
+		it.index = 0
+	}
+
+	return true
+}
+
+func (it *ScanIterator) Val() string {
+	if it.index < 0 || it.index >= len(it.page) {
+		return ""
+	}
+
+	return it.page[it.index]
+}
+
+func (it *ScanIterator) Err() error {
+	return it.err
+}
+
+// Cursor returns the cursor to resume from with a new iterator.
+// Items of the current page may be returned again, as SCAN itself allows.
+func (it *ScanIterator) Cursor() uint64 {
+	if it.started && it.index+it.step >= len(it.page) {
+		return it.next
+	}
+
+	return it.cursor
+}
+
+type HashScanIterator struct {
+	*ScanIterator
+}
+
+func (it HashScanIterator) Field() string {
+	return it.Val()
+}
+
+func (it HashScanIterator) Value() string {
+	if it.index < 0 || it.index+1 >= len(it.page) {
+		return ""
+	}
+
+	return it.page[it.index+1]
+}
+
+type KeyScanIterator struct {
+	*ScanIterator
+}
+
+func (it KeyScanIterator) Type() string {
+	if it.index < 0 || it.index+1 >= len(it.page) {
+		return ""
+	}
+
+	return it.page[it.index+1]
+}
+
+// Key returns *StringKey, *HashSetKey, *SetKey, *StreamKey, *ListKey or *SortedSetKey by the type of the key, *Key for other types.
+func (it KeyScanIterator) Key() interface{} {
+	key := it.Val()
+	switch it.Type() {
+	case KeyTypeString:
+		return NewStringKey(key)
+	case KeyTypeHash:
+		return NewHashSetKey(key)
+	case KeyTypeSet:
+		return NewSetKey(key)
+	case KeyTypeStream:
+		return NewStreamKey(key)
+	case KeyTypeList:
+		return NewListKey(key)
+	case KeyTypeZSet:
+		return NewSortedSetKey(key)
+	default:
+		return NewKey(key)
+	}
+}
+
+// ScanKeys iterates the keyspace, keyType filters keys by type unless it is empty.
+func ScanKeys(cursor uint64, match string, count int64, keyType string) KeyScanIterator {
+	return KeyScanIterator{newScanIterator(cursor, 2, func(ctx context.Context, cursor uint64) ([]string, uint64, error) {
+		start := time.Now()
+		client := MustGetRedis(ctx)
+
+		var keys []string
+		var next uint64
+		var err error
+		if keyType == "" {
+			keys, next, err = client.Scan(ctx, cursor, match, count).Result()
+		} else {
+			keys, next, err = client.ScanType(ctx, cursor, match, count, keyType).Result()
+		}
+		if err != nil {
+			log.Warn(ctx, "scan keys failed",
+				log.Err(err),
+				log.Uint64("cursor", cursor),
+				log.String("match", match),
+				log.String("type", keyType),
+				log.Duration("duration", time.Since(start)))
+			return nil, 0, err
+		}
+
+		page := make([]string, 0, len(keys)*2)
+		if keyType != "" {
+			for _, key := range keys {
+				page = append(page, key, keyType)
+			}
+		} else if len(keys) > 0 {
+			cmds := make([]*redis.StatusCmd, len(keys))
+			_, err = client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+				for index, key := range keys {
+					cmds[index] = pipe.Type(ctx, key)
+				}
+				return nil
+			})
+			if err != nil {
+				log.Warn(ctx, "get key types failed",
+					log.Err(err),
+					log.Strings("keys", keys),
+					log.Duration("duration", time.Since(start)))
+				return nil, 0, err
+			}
+
+			for index, key := range keys {
+				page = append(page, key, cmds[index].Val())
+			}
+		}
+
+		log.Debug(ctx, "scan keys successfully",
+			log.Uint64("cursor", cursor),
+			log.String("match", match),
+			log.String("type", keyType),
+			log.Uint64("next", next),
+			log.Int("count", len(keys)),
+			log.Duration("duration", time.Since(start)))
+
+		return page, next, nil
+	})}
+}
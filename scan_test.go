@@ -0,0 +1,126 @@
+package ro
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestSetKey_SScanIterator(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewSetKey("scan:set")
+	defer key.Del(ctx)
+
+	members := make([]string, 100)
+	for index := range members {
+		members[index] = fmt.Sprintf("m%d", index)
+	}
+
+	err := key.SAdd(ctx, members...)
+	if err != nil {
+		t.Fatalf("add members failed due to %v", err)
+	}
+
+	found := make(map[string]struct{})
+	it := key.SScanIterator(0, "m1*", 10)
+	for it.Next(ctx) {
+		found[it.Val()] = struct{}{}
+	}
+
+	if it.Err() != nil {
+		t.Errorf("scan members failed due to %v", it.Err())
+	}
+
+	if len(found) != 11 {
+		t.Errorf("get unexpect members count, want 11, get %d", len(found))
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	it = key.SScanIterator(0, "", 10)
+	if it.Next(cancelled) || it.Err() != context.Canceled {
+		t.Errorf("scan with cancelled context should fail, get %v", it.Err())
+	}
+}
+
+func TestHashSetKey_HScanIterator(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewHashSetKey("scan:hash")
+	defer key.Del(ctx)
+
+	_, err := key.HSetMap(ctx, map[string]string{"a": "1", "b": "2", "c": "3"})
+	if err != nil {
+		t.Fatalf("set fields failed due to %v", err)
+	}
+
+	found := make(map[string]string)
+	it := key.HScanIterator(0, "", 1)
+	for it.Next(ctx) {
+		found[it.Field()] = it.Value()
+	}
+
+	if it.Err() != nil {
+		t.Errorf("scan fields failed due to %v", it.Err())
+	}
+
+	if len(found) != 3 || found["b"] != "2" {
+		t.Errorf("get unexpect fields %v", found)
+	}
+}
+
+func TestScanKeys(t *testing.T) {
+	ctx := context.Background()
+
+	stringKey := NewStringKey("scan:keys:string")
+	defer stringKey.Del(ctx)
+
+	setKey := NewSetKey("scan:keys:set")
+	defer setKey.Del(ctx)
+
+	err := stringKey.Set(ctx, "1", 0)
+	if err != nil {
+		t.Fatalf("set value failed due to %v", err)
+	}
+
+	err = setKey.SAdd(ctx, "1")
+	if err != nil {
+		t.Fatalf("add member failed due to %v", err)
+	}
+
+	types := make(map[string]string)
+	it := ScanKeys(0, "scan:keys:*", 10, "")
+	for it.Next(ctx) {
+		switch key := it.Key().(type) {
+		case *StringKey:
+			types[key.Key.Key()] = KeyTypeString
+		case *SetKey:
+			types[key.Key.Key()] = KeyTypeSet
+		default:
+			t.Errorf("get unexpect key %v", key)
+		}
+	}
+
+	if it.Err() != nil {
+		t.Errorf("scan keys failed due to %v", it.Err())
+	}
+
+	if types["scan:keys:string"] != KeyTypeString || types["scan:keys:set"] != KeyTypeSet {
+		t.Errorf("get unexpect key types %v", types)
+	}
+
+	it = ScanKeys(0, "scan:keys:*", 10, KeyTypeSet)
+	count := 0
+	for it.Next(ctx) {
+		count++
+		if _, ok := it.Key().(*SetKey); !ok {
+			t.Errorf("get unexpect key %v", it.Key())
+		}
+	}
+
+	if count != 1 {
+		t.Errorf("get unexpect set keys count, want 1, get %d", count)
+	}
+}
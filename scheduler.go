@@ -0,0 +1,307 @@
+package ro
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// SchedulerKeyPattern keeps all keys of a scheduler in the same cluster slot.
+	SchedulerKeyPattern = "ro:scheduler:{%s}"
+
+	defaultSchedulerBatchSize = 100
+)
+
+var (
+	// schedulerDispatchScript moves due jobs to the ready list or stream.
+	// KEYS are the due sorted set, the payload hash and the ready key.
+	// ARGV[1] is the unix milliseconds now, ARGV[2] is the max jobs to move, ARGV[3] is 1 for a stream ready key,
+	// ARGV[4] is the approximate max length of the stream, 0 for no limit.
+	schedulerDispatchScript = redis.NewScript(`
+local jobs = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'WITHSCORES', 'LIMIT', 0, tonumber(ARGV[2]))
+for index = 1, #jobs, 2 do
+	local id, due = jobs[index], string.format('%.0f', tonumber(jobs[index + 1]))
+	local payload = redis.call('HGET', KEYS[2], id) or ''
+	if ARGV[3] == '1' then
+		if tonumber(ARGV[4]) > 0 then
+			redis.call('XADD', KEYS[3], 'MAXLEN', '~', ARGV[4], '*', 'id', id, 'payload', payload, 'due', due)
+		else
+			redis.call('XADD', KEYS[3], '*', 'id', id, 'payload', payload, 'due', due)
+		end
+	else
+		redis.call('RPUSH', KEYS[3], cjson.encode({id = id, payload = payload, due = due}))
+	end
+	redis.call('ZREM', KEYS[1], id)
+	redis.call('HDEL', KEYS[2], id)
+end
+return #jobs / 2
+`)
+
+	// schedulerCancelScript KEYS are the due sorted set and the payload hash, ARGV[1] is the job id.
+	schedulerCancelScript = redis.NewScript(`
+redis.call('HDEL', KEYS[2], ARGV[1])
+return redis.call('ZREM', KEYS[1], ARGV[1])
+`)
+)
+
+// Clock tells the scheduler what time it is, it can be replaced in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+type SchedulerOption struct {
+	// Clock is the system clock if nil.
+	Clock Clock
+	// Stream dispatches due jobs to a stream with id, payload and due fields, instead of a list.
+	Stream bool
+	// MaxLen trims the ready stream approximately.
+	MaxLen int64
+	// BatchSize is the max jobs moved by one dispatch.
+	BatchSize int
+}
+
+// Scheduler keeps jobs until they are due, then moves them to the ready list or stream.
+type Scheduler struct {
+	key      string
+	due      *SortedSetKey
+	payloads *HashSetKey
+	ready    *Key
+	option   SchedulerOption
+}
+
+type ScheduledJob struct {
+	ID      string `json:"id"`
+	Payload string `json:"payload"`
+	// Due is the unix milliseconds the job is due.
+	Due int64 `json:"due,string"`
+}
+
+func NewScheduler(name string, option *SchedulerOption) *Scheduler {
+	key := fmt.Sprintf(SchedulerKeyPattern, name)
+	s := &Scheduler{
+		key:      key,
+		due:      NewSortedSetKey(key + ":due"),
+		payloads: NewHashSetKey(key + ":payloads"),
+		ready:    NewKey(key + ":ready"),
+	}
+
+	if option != nil {
+		s.option = *option
+	}
+
+	if s.option.Clock == nil {
+		s.option.Clock = systemClock{}
+	}
+
+	if s.option.BatchSize <= 0 {
+		s.option.BatchSize = defaultSchedulerBatchSize
+	}
+
+	return s
+}
+
+// Ready returns the list the due jobs are dispatched to, they are JSON encoded ScheduledJob.
+func (s *Scheduler) Ready() *ListKey {
+	return &ListKey{Key: s.ready}
+}
+
+// ReadyStream returns the stream the due jobs are dispatched to with the Stream option.
+func (s *Scheduler) ReadyStream() *StreamKey {
+	return &StreamKey{Key: s.ready}
+}
+
+// Schedule adds the job, or replaces the payload and due time if id has been scheduled.
+func (s *Scheduler) Schedule(ctx context.Context, id, payload string, due time.Time) error {
+	start := time.Now()
+	_, err := MustGetRedis(ctx).TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, s.payloads.key, id, payload)
+		pipe.ZAdd(ctx, s.due.key, redis.Z{Member: id, Score: float64(due.UnixMilli())})
+		return nil
+	})
+	if err != nil {
+		log.Warn(ctx, "schedule job failed",
+			log.Err(err),
+			log.String("key", s.key),
+			log.String("id", id),
+			log.Time("due", due),
+			log.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	log.Debug(ctx, "schedule job successfully",
+		log.String("key", s.key),
+		log.String("id", id),
+		log.Time("due", due),
+		log.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+func (s *Scheduler) ScheduleAfter(ctx context.Context, id, payload string, delay time.Duration) error {
+	return s.Schedule(ctx, id, payload, s.option.Clock.Now().Add(delay))
+}
+
+// Reschedule changes the due time of a pending job, it returns false if the job is not pending.
+func (s *Scheduler) Reschedule(ctx context.Context, id string, due time.Time) (bool, error) {
+	changed, err := s.due.ZAddArgs(ctx, redis.ZAddArgs{
+		XX:      true,
+		Ch:      true,
+		Members: []redis.Z{{Member: id, Score: float64(due.UnixMilli())}},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if changed > 0 {
+		return true, nil
+	}
+
+	// the due time may be unchanged
+	_, err = s.due.ZScore(ctx, id)
+	if err == redis.Nil {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// Cancel removes a pending job, it returns false if the job is not pending.
+func (s *Scheduler) Cancel(ctx context.Context, id string) (bool, error) {
+	start := time.Now()
+	removed, err := schedulerCancelScript.Run(ctx, MustGetRedis(ctx), []string{s.due.key, s.payloads.key}, id).Int64()
+	if err != nil {
+		log.Warn(ctx, "cancel job failed",
+			log.Err(err),
+			log.String("key", s.key),
+			log.String("id", id),
+			log.Duration("duration", time.Since(start)))
+		return false, err
+	}
+
+	log.Debug(ctx, "cancel job successfully",
+		log.String("key", s.key),
+		log.String("id", id),
+		log.Bool("removed", removed > 0),
+		log.Duration("duration", time.Since(start)))
+
+	return removed > 0, nil
+}
+
+// Due returns the due time of a pending job, it returns ErrRecordNotFound if the job is not pending.
+func (s *Scheduler) Due(ctx context.Context, id string) (time.Time, error) {
+	score, err := s.due.ZScore(ctx, id)
+	if err == redis.Nil {
+		return time.Time{}, ErrRecordNotFound
+	}
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.UnixMilli(int64(score)), nil
+}
+
+func (s *Scheduler) Pending(ctx context.Context) (int64, error) {
+	return s.due.ZCard(ctx)
+}
+
+// Dispatch moves the due jobs to the ready list or stream, it returns how many jobs are moved.
+func (s *Scheduler) Dispatch(ctx context.Context) (int64, error) {
+	start := time.Now()
+	now := s.option.Clock.Now()
+	stream := 0
+	if s.option.Stream {
+		stream = 1
+	}
+
+	count, err := schedulerDispatchScript.Run(ctx, MustGetRedis(ctx), []string{s.due.key, s.payloads.key, s.ready.key},
+		now.UnixMilli(), s.option.BatchSize, stream, s.option.MaxLen).Int64()
+	if err != nil {
+		log.Warn(ctx, "dispatch jobs failed",
+			log.Err(err),
+			log.String("key", s.key),
+			log.Time("now", now),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "dispatch jobs successfully",
+		log.String("key", s.key),
+		log.Time("now", now),
+		log.Int64("count", count),
+		log.Duration("duration", time.Since(start)))
+
+	return count, nil
+}
+
+// Run dispatches due jobs every interval until ctx is done, full batches are followed by the next one at once.
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) error {
+	for {
+		count, err := s.Dispatch(ctx)
+		if err != nil || count < int64(s.option.BatchSize) {
+			err = sleepContext(ctx, interval)
+		} else {
+			err = ctx.Err()
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Receive pops the next job from the ready list, it returns ErrRecordNotFound when timeout.
+func (s *Scheduler) Receive(ctx context.Context, timeout time.Duration) (*ScheduledJob, error) {
+	_, value, err := s.Ready().BLPop(ctx, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeScheduledJob(ctx, value)
+}
+
+func decodeScheduledJob(ctx context.Context, value string) (*ScheduledJob, error) {
+	job := new(ScheduledJob)
+	err := json.Unmarshal([]byte(value), job)
+	if err != nil {
+		log.Warn(ctx, "json unmarshal failed",
+			log.Err(err),
+			log.String("value", value))
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// ScheduledJobFromMessage decodes a job dispatched to the ready stream.
+func ScheduledJobFromMessage(message redis.XMessage) (*ScheduledJob, error) {
+	job := &ScheduledJob{}
+	id, ok := message.Values["id"].(string)
+	if !ok {
+		return nil, ErrInvalidEncodedValue
+	}
+
+	job.ID = id
+	job.Payload, _ = message.Values["payload"].(string)
+	due, _ := message.Values["due"].(string)
+
+	var err error
+	job.Due, err = strconv.ParseInt(due, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidEncodedValue
+	}
+
+	return job, nil
+}
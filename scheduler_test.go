@@ -0,0 +1,147 @@
+package ro
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func deleteScheduler(ctx context.Context, s *Scheduler) {
+	_ = s.due.Del(ctx)
+	_ = s.payloads.Del(ctx)
+	_ = s.ready.Del(ctx)
+}
+
+func TestScheduler_Dispatch(t *testing.T) {
+	ctx := context.Background()
+
+	clock := &testClock{now: time.UnixMilli(1700000000000)}
+	s := NewScheduler("test:dispatch", &SchedulerOption{Clock: clock})
+	defer deleteScheduler(ctx, s)
+
+	_ = s.ScheduleAfter(ctx, "reminder", "remind me", 3*24*time.Hour)
+	_ = s.ScheduleAfter(ctx, "soon", "soon", time.Minute)
+	_ = s.ScheduleAfter(ctx, "canceled", "canceled", time.Minute)
+
+	canceled, err := s.Cancel(ctx, "canceled")
+	if err != nil || !canceled {
+		t.Errorf("cancel failed, get %v, %v", canceled, err)
+	}
+
+	canceled, err = s.Cancel(ctx, "canceled")
+	if err != nil || canceled {
+		t.Errorf("cancel twice should return false, get %v, %v", canceled, err)
+	}
+
+	count, err := s.Dispatch(ctx)
+	if err != nil || count != 0 {
+		t.Errorf("dispatch before due failed, get %d, %v", count, err)
+	}
+
+	clock.Add(2 * time.Minute)
+	count, err = s.Dispatch(ctx)
+	if err != nil || count != 1 {
+		t.Errorf("dispatch due jobs failed, get %d, %v", count, err)
+	}
+
+	job, err := s.Receive(ctx, time.Second)
+	if err != nil || job.ID != "soon" || job.Payload != "soon" || job.Due != 1700000060000 {
+		t.Errorf("receive failed, get %+v, %v", job, err)
+	}
+
+	rescheduled, err := s.Reschedule(ctx, "reminder", clock.Now())
+	if err != nil || !rescheduled {
+		t.Errorf("reschedule failed, get %v, %v", rescheduled, err)
+	}
+
+	rescheduled, err = s.Reschedule(ctx, "reminder", clock.Now())
+	if err != nil || !rescheduled {
+		t.Errorf("reschedule to the same time should succeed, get %v, %v", rescheduled, err)
+	}
+
+	rescheduled, err = s.Reschedule(ctx, "soon", clock.Now())
+	if err != nil || rescheduled {
+		t.Errorf("reschedule dispatched job should return false, get %v, %v", rescheduled, err)
+	}
+
+	due, err := s.Due(ctx, "reminder")
+	if err != nil || !due.Equal(clock.Now()) {
+		t.Errorf("due failed, get %v, %v", due, err)
+	}
+
+	count, err = s.Dispatch(ctx)
+	if err != nil || count != 1 {
+		t.Errorf("dispatch rescheduled job failed, get %d, %v", count, err)
+	}
+
+	pending, err := s.Pending(ctx)
+	if err != nil || pending != 0 {
+		t.Errorf("pending failed, get %d, %v", pending, err)
+	}
+
+	_, err = s.Due(ctx, "reminder")
+	if err != ErrRecordNotFound {
+		t.Errorf("due of dispatched job should return ErrRecordNotFound, get %v", err)
+	}
+}
+
+func TestScheduler_Stream(t *testing.T) {
+	ctx := context.Background()
+
+	clock := &testClock{now: time.UnixMilli(1700000000000)}
+	s := NewScheduler("test:stream", &SchedulerOption{Clock: clock, Stream: true, MaxLen: 1000})
+	defer deleteScheduler(ctx, s)
+
+	_ = s.Schedule(ctx, "job1", "payload1", clock.Now())
+
+	count, err := s.Dispatch(ctx)
+	if err != nil || count != 1 {
+		t.Fatalf("dispatch failed, get %d, %v", count, err)
+	}
+
+	messages, err := MustGetRedis(ctx).XRange(ctx, s.ReadyStream().key, "-", "+").Result()
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("xrange failed, get %v, %v", messages, err)
+	}
+
+	job, err := ScheduledJobFromMessage(messages[0])
+	if err != nil || job.ID != "job1" || job.Payload != "payload1" || job.Due != 1700000000000 {
+		t.Errorf("decode job failed, get %+v, %v", job, err)
+	}
+}
+
+func TestScheduler_ConcurrentDispatch(t *testing.T) {
+	ctx := context.Background()
+
+	clock := &testClock{now: time.UnixMilli(1700000000000)}
+	s := NewScheduler("test:concurrent", &SchedulerOption{Clock: clock, BatchSize: 7})
+	defer deleteScheduler(ctx, s)
+
+	for index := 0; index < 100; index++ {
+		_ = s.Schedule(ctx, time.Duration(index).String(), "", clock.Now())
+	}
+
+	var dispatched int64
+	var wg sync.WaitGroup
+	for index := 0; index < 5; index++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				count, err := s.Dispatch(ctx)
+				if err != nil || count == 0 {
+					return
+				}
+				atomic.AddInt64(&dispatched, count)
+			}
+		}()
+	}
+	wg.Wait()
+
+	length, err := s.Ready().LLen(ctx)
+	if err != nil || dispatched != 100 || length != 100 {
+		t.Errorf("jobs should be dispatched once, get %d dispatched, %d ready, %v", dispatched, length, err)
+	}
+}
@@ -0,0 +1,204 @@
+package ro
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	schemas      = make(map[reflect.Type]*Schema)
+	schemasMutex sync.RWMutex
+
+	// KEYS[1] string key, ARGV[1] expected value, ARGV[2] new value.
+	replaceStringScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[2], 'KEEPTTL')
+	return 1
+end
+return 0
+`)
+
+	// KEYS[1] hash key, ARGV[1] field, ARGV[2] expected value, ARGV[3] new value.
+	replaceHashFieldScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], ARGV[1]) == ARGV[2] then
+	redis.call('HSET', KEYS[1], ARGV[1], ARGV[3])
+	return 1
+end
+return 0
+`)
+)
+
+// SchemaUpgrade converts the json of an object from one version to the next one.
+type SchemaUpgrade func(data []byte) ([]byte, error)
+
+type Schema struct {
+	version   int
+	upgrades  map[int]SchemaUpgrade
+	writeBack bool
+}
+
+// schemaEnvelope wraps objects of registered types,
+// values stored without it are treated as version 1.
+type schemaEnvelope struct {
+	Version *int            `json:"$v"`
+	Data    json.RawMessage `json:"$d"`
+}
+
+// RegisterSchema makes SetObject and HSetObject store objects of the type of obj with the version,
+// and GetObject and HGetObject upgrade older versions before decoding.
+func RegisterSchema(obj interface{}, version int) *Schema {
+	schema := &Schema{
+		version:  version,
+		upgrades: make(map[int]SchemaUpgrade),
+	}
+
+	schemasMutex.Lock()
+	schemas[schemaType(obj)] = schema
+	schemasMutex.Unlock()
+
+	return schema
+}
+
+func (s *Schema) Upgrade(from int, upgrade SchemaUpgrade) *Schema {
+	s.upgrades[from] = upgrade
+	return s
+}
+
+// WriteBack stores the upgraded form when an older version is read.
+func (s *Schema) WriteBack() *Schema {
+	s.writeBack = true
+	return s
+}
+
+func schemaType(obj interface{}) reflect.Type {
+	t := reflect.TypeOf(obj)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+func getSchema(obj interface{}) *Schema {
+	schemasMutex.RLock()
+	defer schemasMutex.RUnlock()
+
+	return schemas[schemaType(obj)]
+}
+
+func marshalObject(obj interface{}) ([]byte, error) {
+	buffer, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := getSchema(obj)
+	if schema == nil {
+		return buffer, nil
+	}
+
+	return json.Marshal(schemaEnvelope{
+		Version: &schema.version,
+		Data:    buffer,
+	})
+}
+
+// unmarshalObject returns the upgraded value when it should be written back.
+func unmarshalObject(value string, obj interface{}) (string, error) {
+	schema := getSchema(obj)
+	if schema == nil {
+		return "", json.Unmarshal([]byte(value), obj)
+	}
+
+	version := 1
+	data := []byte(value)
+
+	envelope := schemaEnvelope{}
+	err := json.Unmarshal(data, &envelope)
+	if err == nil && envelope.Version != nil && envelope.Data != nil {
+		version = *envelope.Version
+		data = envelope.Data
+	}
+
+	if version > schema.version {
+		return "", ErrUnsupportedSchemaVersion
+	}
+
+	upgraded := version < schema.version
+	for ; version < schema.version; version++ {
+		upgrade, found := schema.upgrades[version]
+		if !found {
+			return "", ErrUnsupportedSchemaVersion
+		}
+
+		data, err = upgrade(data)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = json.Unmarshal(data, obj)
+	if err != nil {
+		return "", err
+	}
+
+	if !upgraded || !schema.writeBack {
+		return "", nil
+	}
+
+	buffer, err := json.Marshal(schemaEnvelope{
+		Version: &schema.version,
+		Data:    data,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(buffer), nil
+}
+
+func (k StringKey) writeBackObject(ctx context.Context, value, upgraded string) {
+	start := time.Now()
+	replaced, err := replaceStringScript.Run(ctx, MustGetRedis(ctx), []string{k.key}, value, upgraded).Bool()
+	if err != nil {
+		log.Warn(ctx, "write back upgraded object failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("value", upgraded),
+			log.Duration("duration", time.Since(start)))
+		return
+	}
+
+	log.Debug(ctx, "write back upgraded object finished",
+		log.String("key", k.key),
+		log.String("value", upgraded),
+		log.Bool("replaced", replaced),
+		log.Duration("duration", time.Since(start)))
+}
+
+func (k HashSetKey) writeBackObject(ctx context.Context, field, value, upgraded string) {
+	start := time.Now()
+	replaced, err := replaceHashFieldScript.Run(ctx, MustGetRedis(ctx), []string{k.key}, field, value, upgraded).Bool()
+	if err != nil {
+		log.Warn(ctx, "write back upgraded object failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("field", field),
+			log.String("value", upgraded),
+			log.Duration("duration", time.Since(start)))
+		return
+	}
+
+	log.Debug(ctx, "write back upgraded object finished",
+		log.String("key", k.key),
+		log.String("field", field),
+		log.String("value", upgraded),
+		log.Bool("replaced", replaced),
+		log.Duration("duration", time.Since(start)))
+}
@@ -0,0 +1,126 @@
+package ro
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type testSchemaUser struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Age       int    `json:"age"`
+}
+
+func init() {
+	RegisterSchema(&testSchemaUser{}, 3).
+		Upgrade(1, func(data []byte) ([]byte, error) {
+			// v1 stored the full name in one field
+			var v1 struct {
+				Name string `json:"name"`
+				Age  int    `json:"age"`
+			}
+			err := json.Unmarshal(data, &v1)
+			if err != nil {
+				return nil, err
+			}
+
+			return json.Marshal(map[string]interface{}{"first_name": v1.Name, "age": v1.Age})
+		}).
+		Upgrade(2, func(data []byte) ([]byte, error) {
+			// v2 has no last name
+			var v2 map[string]interface{}
+			err := json.Unmarshal(data, &v2)
+			if err != nil {
+				return nil, err
+			}
+
+			v2["last_name"] = "unknown"
+			return json.Marshal(v2)
+		}).
+		WriteBack()
+}
+
+func TestStringKey_GetObjectUpgrade(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewStringKey("schema:test1")
+	defer key.Del(ctx)
+
+	err := key.Set(ctx, `{"name":"tom","age":18}`, time.Minute)
+	if err != nil {
+		t.Errorf("set legacy value failed due to %v", err)
+	}
+
+	get := &testSchemaUser{}
+	err = key.GetObject(ctx, get)
+	if err != nil {
+		t.Errorf("get object failed due to %v", err)
+	}
+
+	want := testSchemaUser{FirstName: "tom", LastName: "unknown", Age: 18}
+	if *get != want {
+		t.Errorf("get object not equal, want %v, get %v", want, *get)
+	}
+
+	value, err := key.Get(ctx)
+	if err != nil {
+		t.Errorf("get value failed due to %v", err)
+	}
+
+	if value != `{"$v":3,"$d":{"age":18,"first_name":"tom","last_name":"unknown"}}` {
+		t.Errorf("upgraded object should be written back, get %s", value)
+	}
+
+	ttl, err := key.TTL(ctx)
+	if err != nil || ttl <= 0 {
+		t.Errorf("write back should keep ttl, get %v, %v", ttl, err)
+	}
+}
+
+func TestHashSetKey_HGetObjectUpgrade(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewHashSetKey("schema:test2")
+	defer key.Del(ctx)
+
+	err := key.HSet(ctx, "1", `{"$v":2,"$d":{"first_name":"tom","age":18}}`)
+	if err != nil {
+		t.Errorf("set legacy value failed due to %v", err)
+	}
+
+	get := &testSchemaUser{}
+	err = key.HGetObject(ctx, "1", get)
+	if err != nil {
+		t.Errorf("get object failed due to %v", err)
+	}
+
+	if get.LastName != "unknown" {
+		t.Errorf("object should be upgraded, get %v", get)
+	}
+
+	err = key.HSetObject(ctx, "2", &testSchemaUser{FirstName: "jerry"})
+	if err != nil {
+		t.Errorf("set object failed due to %v", err)
+	}
+
+	value, err := key.HGet(ctx, "2")
+	if err != nil {
+		t.Errorf("get value failed due to %v", err)
+	}
+
+	if value != `{"$v":3,"$d":{"first_name":"jerry","last_name":"","age":0}}` {
+		t.Errorf("object should be stored in envelope, get %s", value)
+	}
+
+	err = key.HSet(ctx, "3", `{"$v":4,"$d":{}}`)
+	if err != nil {
+		t.Errorf("set value failed due to %v", err)
+	}
+
+	err = key.HGetObject(ctx, "3", get)
+	if err != ErrUnsupportedSchemaVersion {
+		t.Errorf("newer version should not be decoded, get %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
 )
 
 var (
@@ -33,8 +34,19 @@ func NewSetKey(key string) *SetKey {
 }
 
 func (k SetKey) SAdd(ctx context.Context, members ...string) error {
+	_, err := k.SAddCount(ctx, members...)
+	return err
+}
+
+func (k SetKey) SAddCount(ctx context.Context, members ...string) (int64, error) {
 	if len(members) == 0 {
-		return nil
+		return 0, nil
+	}
+
+	if len(members) > getChunkOption().ChunkSize {
+		return chunkedMembers(ctx, "set members", k.key, members, func(ctx context.Context, pipe redis.Pipeliner, members []string) *redis.IntCmd {
+			return pipe.SAdd(ctx, k.key, stringsToInterfaces(members)...)
+		})
 	}
 
 	start := time.Now()
@@ -43,27 +55,39 @@ func (k SetKey) SAdd(ctx context.Context, members ...string) error {
 		parameters[index] = member
 	}
 
-	err := MustGetRedis(ctx).SAdd(ctx, k.key, parameters...).Err()
+	count, err := MustGetRedis(ctx).SAdd(ctx, k.key, parameters...).Result()
 	if err != nil {
 		log.Warn(ctx, "set members failed",
 			log.Err(err),
 			log.String("key", k.key),
 			log.Any("members", parameters),
 			log.Duration("duration", time.Since(start)))
-		return err
+		return 0, err
 	}
 
 	log.Debug(ctx, "set member successfully",
 		log.String("key", k.key),
 		log.Any("members", parameters),
+		log.Int64("count", count),
 		log.Duration("duration", time.Since(start)))
 
-	return nil
+	return count, nil
 }
 
 func (k SetKey) SRem(ctx context.Context, members ...string) error {
+	_, err := k.SRemCount(ctx, members...)
+	return err
+}
+
+func (k SetKey) SRemCount(ctx context.Context, members ...string) (int64, error) {
 	if len(members) == 0 {
-		return nil
+		return 0, nil
+	}
+
+	if len(members) > getChunkOption().ChunkSize {
+		return chunkedMembers(ctx, "del members", k.key, members, func(ctx context.Context, pipe redis.Pipeliner, members []string) *redis.IntCmd {
+			return pipe.SRem(ctx, k.key, stringsToInterfaces(members)...)
+		})
 	}
 
 	start := time.Now()
@@ -72,22 +96,23 @@ func (k SetKey) SRem(ctx context.Context, members ...string) error {
 		parameters[index] = member
 	}
 
-	err := MustGetRedis(ctx).SRem(ctx, k.key, parameters...).Err()
+	count, err := MustGetRedis(ctx).SRem(ctx, k.key, parameters...).Result()
 	if err != nil {
 		log.Warn(ctx, "del members failed",
 			log.Err(err),
 			log.String("key", k.key),
 			log.Any("members", parameters),
 			log.Duration("duration", time.Since(start)))
-		return err
+		return 0, err
 	}
 
 	log.Debug(ctx, "del member successfully",
 		log.String("key", k.key),
 		log.Any("members", parameters),
+		log.Int64("count", count),
 		log.Duration("duration", time.Since(start)))
 
-	return nil
+	return count, nil
 }
 
 func (k SetKey) SIsMember(ctx context.Context, member string) (bool, error) {
@@ -166,13 +191,290 @@ func (k SetKey) SCard(ctx context.Context) (int64, error) {
 	return count, nil
 }
 
+func (k SetKey) SMIsMember(ctx context.Context, members ...string) ([]bool, error) {
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	start := time.Now()
+	parameters := make([]interface{}, len(members))
+	for index, member := range members {
+		parameters[index] = member
+	}
+
+	found, err := MustGetRedis(ctx).SMIsMember(ctx, k.key, parameters...).Result()
+	if err != nil {
+		log.Warn(ctx, "check members failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int("count", len(members)),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, "check members successfully",
+		log.String("key", k.key),
+		log.Int("count", len(members)),
+		log.Duration("duration", time.Since(start)))
+
+	return found, nil
+}
+
+func (k SetKey) SInter(ctx context.Context, others ...*SetKey) ([]string, error) {
+	return k.combine(ctx, "inter", others, MustGetRedis(ctx).SInter)
+}
+
+func (k SetKey) SUnion(ctx context.Context, others ...*SetKey) ([]string, error) {
+	return k.combine(ctx, "union", others, MustGetRedis(ctx).SUnion)
+}
+
+// SDiff returns the members of k which are not in any of others.
+func (k SetKey) SDiff(ctx context.Context, others ...*SetKey) ([]string, error) {
+	return k.combine(ctx, "diff", others, MustGetRedis(ctx).SDiff)
+}
+
+func (k SetKey) combine(ctx context.Context, operation string, others []*SetKey, fn func(context.Context, ...string) *redis.StringSliceCmd) ([]string, error) {
+	start := time.Now()
+	keys := k.withOthers(others)
+	members, err := fn(ctx, keys...).Result()
+	if err != nil {
+		log.Warn(ctx, operation+" members failed",
+			log.Err(err),
+			log.Strings("keys", keys),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, operation+" members successfully",
+		log.Strings("keys", keys),
+		log.Int("count", len(members)),
+		log.Duration("duration", time.Since(start)))
+
+	return members, nil
+}
+
+// SInterStore stores the intersection into destination and returns its size, expiration 0 keeps destination persistent.
+func (k SetKey) SInterStore(ctx context.Context, destination *SetKey, expiration time.Duration, others ...*SetKey) (int64, error) {
+	return k.combineStore(ctx, "inter", destination, expiration, others, func(pipe redis.Pipeliner, keys []string) *redis.IntCmd {
+		return pipe.SInterStore(ctx, destination.key, keys...)
+	})
+}
+
+func (k SetKey) SUnionStore(ctx context.Context, destination *SetKey, expiration time.Duration, others ...*SetKey) (int64, error) {
+	return k.combineStore(ctx, "union", destination, expiration, others, func(pipe redis.Pipeliner, keys []string) *redis.IntCmd {
+		return pipe.SUnionStore(ctx, destination.key, keys...)
+	})
+}
+
+func (k SetKey) SDiffStore(ctx context.Context, destination *SetKey, expiration time.Duration, others ...*SetKey) (int64, error) {
+	return k.combineStore(ctx, "diff", destination, expiration, others, func(pipe redis.Pipeliner, keys []string) *redis.IntCmd {
+		return pipe.SDiffStore(ctx, destination.key, keys...)
+	})
+}
+
+func (k SetKey) combineStore(ctx context.Context, operation string, destination *SetKey, expiration time.Duration, others []*SetKey, fn func(redis.Pipeliner, []string) *redis.IntCmd) (int64, error) {
+	start := time.Now()
+	keys := k.withOthers(others)
+
+	var cmd *redis.IntCmd
+	_, err := MustGetRedis(ctx).TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		cmd = fn(pipe, keys)
+		if expiration > 0 {
+			pipe.PExpire(ctx, destination.key, expiration)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Warn(ctx, operation+" store members failed",
+			log.Err(err),
+			log.Strings("keys", keys),
+			log.String("destination", destination.key),
+			log.Duration("expiration", expiration),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	count := cmd.Val()
+	log.Debug(ctx, operation+" store members successfully",
+		log.Strings("keys", keys),
+		log.String("destination", destination.key),
+		log.Duration("expiration", expiration),
+		log.Int64("count", count),
+		log.Duration("duration", time.Since(start)))
+
+	return count, nil
+}
+
+// SInterCard returns the size of the intersection, counting stops at limit unless it is 0.
+func (k SetKey) SInterCard(ctx context.Context, limit int64, others ...*SetKey) (int64, error) {
+	start := time.Now()
+	keys := k.withOthers(others)
+	count, err := MustGetRedis(ctx).SInterCard(ctx, limit, keys...).Result()
+	if err != nil {
+		log.Warn(ctx, "get intersection count failed",
+			log.Err(err),
+			log.Strings("keys", keys),
+			log.Int64("limit", limit),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "get intersection count successfully",
+		log.Strings("keys", keys),
+		log.Int64("limit", limit),
+		log.Int64("count", count),
+		log.Duration("duration", time.Since(start)))
+
+	return count, nil
+}
+
+func (k SetKey) withOthers(others []*SetKey) []string {
+	keys := make([]string, 0, len(others)+1)
+	keys = append(keys, k.key)
+	for _, other := range others {
+		keys = append(keys, other.key)
+	}
+
+	return keys
+}
+
+func (k SetKey) SMove(ctx context.Context, destination *SetKey, member string) (bool, error) {
+	start := time.Now()
+	moved, err := MustGetRedis(ctx).SMove(ctx, k.key, destination.key, member).Result()
+	if err != nil {
+		log.Warn(ctx, "move member failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("destination", destination.key),
+			log.String("member", member),
+			log.Duration("duration", time.Since(start)))
+		return false, err
+	}
+
+	log.Debug(ctx, "move member successfully",
+		log.String("key", k.key),
+		log.String("destination", destination.key),
+		log.String("member", member),
+		log.Bool("moved", moved),
+		log.Duration("duration", time.Since(start)))
+
+	return moved, nil
+}
+
+// SPop returns redis.Nil if the set is empty.
+func (k SetKey) SPop(ctx context.Context) (string, error) {
+	start := time.Now()
+	member, err := MustGetRedis(ctx).SPop(ctx, k.key).Result()
+	if err != nil {
+		log.Warn(ctx, "pop member failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Duration("duration", time.Since(start)))
+		return "", err
+	}
+
+	log.Debug(ctx, "pop member successfully",
+		log.String("key", k.key),
+		log.String("member", member),
+		log.Duration("duration", time.Since(start)))
+
+	return member, nil
+}
+
+func (k SetKey) SPopN(ctx context.Context, count int64) ([]string, error) {
+	start := time.Now()
+	members, err := MustGetRedis(ctx).SPopN(ctx, k.key, count).Result()
+	if err != nil {
+		log.Warn(ctx, "pop members failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int64("count", count),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, "pop members successfully",
+		log.String("key", k.key),
+		log.Strings("members", members),
+		log.Duration("duration", time.Since(start)))
+
+	return members, nil
+}
+
+// SRandMember returns redis.Nil if the set is empty.
+func (k SetKey) SRandMember(ctx context.Context) (string, error) {
+	start := time.Now()
+	member, err := MustGetRedis(ctx).SRandMember(ctx, k.key).Result()
+	if err != nil {
+		log.Warn(ctx, "get random member failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Duration("duration", time.Since(start)))
+		return "", err
+	}
+
+	log.Debug(ctx, "get random member successfully",
+		log.String("key", k.key),
+		log.String("member", member),
+		log.Duration("duration", time.Since(start)))
+
+	return member, nil
+}
+
+// SRandMemberN returns distinct members if count is positive, a negative count allows the same member more than once.
+func (k SetKey) SRandMemberN(ctx context.Context, count int64) ([]string, error) {
+	start := time.Now()
+	members, err := MustGetRedis(ctx).SRandMemberN(ctx, k.key, count).Result()
+	if err != nil {
+		log.Warn(ctx, "get random members failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int64("count", count),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, "get random members successfully",
+		log.String("key", k.key),
+		log.Strings("members", members),
+		log.Duration("duration", time.Since(start)))
+
+	return members, nil
+}
+
+func (k SetKey) SScanIterator(cursor uint64, match string, count int64) *ScanIterator {
+	return newScanIterator(cursor, 1, func(ctx context.Context, cursor uint64) ([]string, uint64, error) {
+		start := time.Now()
+		members, next, err := MustGetRedis(ctx).SScan(ctx, k.key, cursor, match, count).Result()
+		if err != nil {
+			log.Warn(ctx, "scan members failed",
+				log.Err(err),
+				log.String("key", k.key),
+				log.Uint64("cursor", cursor),
+				log.String("match", match),
+				log.Duration("duration", time.Since(start)))
+			return nil, 0, err
+		}
+
+		log.Debug(ctx, "scan members successfully",
+			log.String("key", k.key),
+			log.Uint64("cursor", cursor),
+			log.String("match", match),
+			log.Uint64("next", next),
+			log.Int("count", len(members)),
+			log.Duration("duration", time.Since(start)))
+
+		return members, next, nil
+	})
+}
+
 type SetParameterKey struct {
-	pattern string
+	*ParameterKey
 }
 
 func NewSetParameterKey(pattern string) *SetParameterKey {
 	k := setParameterKeyPool.Get().(*SetParameterKey)
-	k.pattern = pattern
+	k.ParameterKey = NewParameterKey(pattern)
 	return k
 }
 
@@ -2,7 +2,12 @@ package ro
 
 import (
 	"context"
+	"reflect"
+	"sort"
 	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
 func TestSetKey_All(t *testing.T) {
@@ -40,3 +45,141 @@ func TestSetKey_All(t *testing.T) {
 		t.Errorf("memeber %s should not be exists", member)
 	}
 }
+
+func TestSetKey_Count(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewSetKey("set:count")
+	defer key.Del(ctx)
+
+	count, err := key.SAddCount(ctx, "a", "b", "c")
+	if err != nil || count != 3 {
+		t.Errorf("sadd count failed, get %d, %v", count, err)
+	}
+
+	count, err = key.SAddCount(ctx, "a", "d")
+	if err != nil || count != 1 {
+		t.Errorf("sadd count failed, get %d, %v", count, err)
+	}
+
+	found, err := key.SMIsMember(ctx, "a", "e", "d")
+	if err != nil || !reflect.DeepEqual(found, []bool{true, false, true}) {
+		t.Errorf("smismember failed, get %v, %v", found, err)
+	}
+
+	count, err = key.SRemCount(ctx, "a", "e")
+	if err != nil || count != 1 {
+		t.Errorf("srem count failed, get %d, %v", count, err)
+	}
+}
+
+func TestSetKey_Algebra(t *testing.T) {
+	ctx := context.Background()
+
+	key1 := NewSetKey("set:algebra:1")
+	key2 := NewSetKey("set:algebra:2")
+	key3 := NewSetKey("set:algebra:3")
+	destination := NewSetKey("set:algebra:destination")
+	defer key1.Del(ctx)
+	defer key2.Del(ctx)
+	defer key3.Del(ctx)
+	defer destination.Del(ctx)
+
+	_ = key1.SAdd(ctx, "a", "b", "c", "d")
+	_ = key2.SAdd(ctx, "b", "c", "e")
+	_ = key3.SAdd(ctx, "c", "f")
+
+	members, err := key1.SInter(ctx, key2)
+	sort.Strings(members)
+	if err != nil || !reflect.DeepEqual(members, []string{"b", "c"}) {
+		t.Errorf("sinter failed, get %v, %v", members, err)
+	}
+
+	members, err = key1.SUnion(ctx, key2, key3)
+	sort.Strings(members)
+	if err != nil || !reflect.DeepEqual(members, []string{"a", "b", "c", "d", "e", "f"}) {
+		t.Errorf("sunion failed, get %v, %v", members, err)
+	}
+
+	members, err = key1.SDiff(ctx, key2, key3)
+	sort.Strings(members)
+	if err != nil || !reflect.DeepEqual(members, []string{"a", "d"}) {
+		t.Errorf("sdiff failed, get %v, %v", members, err)
+	}
+
+	count, err := key1.SInterCard(ctx, 0, key2)
+	if err != nil || count != 2 {
+		t.Errorf("sintercard failed, get %d, %v", count, err)
+	}
+
+	count, err = key1.SInterCard(ctx, 1, key2)
+	if err != nil || count != 1 {
+		t.Errorf("sintercard with limit failed, get %d, %v", count, err)
+	}
+
+	count, err = key1.SUnionStore(ctx, destination, time.Minute, key3)
+	if err != nil || count != 5 {
+		t.Errorf("sunionstore failed, get %d, %v", count, err)
+	}
+
+	ttl, err := destination.TTL(ctx)
+	if err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("sunionstore should set ttl, get %v, %v", ttl, err)
+	}
+
+	count, err = key1.SInterStore(ctx, destination, 0, key2, key3)
+	if err != nil || count != 1 {
+		t.Errorf("sinterstore failed, get %d, %v", count, err)
+	}
+
+	count, err = key1.SDiffStore(ctx, destination, time.Minute, key2)
+	if err != nil || count != 2 {
+		t.Errorf("sdiffstore failed, get %d, %v", count, err)
+	}
+}
+
+func TestSetKey_MovePopRandom(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewSetKey("set:pop:source")
+	destination := NewSetKey("set:pop:destination")
+	defer key.Del(ctx)
+	defer destination.Del(ctx)
+
+	_ = key.SAdd(ctx, "a", "b", "c", "d")
+
+	moved, err := key.SMove(ctx, destination, "a")
+	if err != nil || !moved {
+		t.Errorf("smove failed, get %v, %v", moved, err)
+	}
+
+	moved, err = key.SMove(ctx, destination, "a")
+	if err != nil || moved {
+		t.Errorf("smove missing member should not move, get %v, %v", moved, err)
+	}
+
+	members, err := key.SRandMemberN(ctx, 2)
+	if err != nil || len(members) != 2 || members[0] == members[1] {
+		t.Errorf("srandmember failed, get %v, %v", members, err)
+	}
+
+	members, err = key.SRandMemberN(ctx, -5)
+	if err != nil || len(members) != 5 {
+		t.Errorf("srandmember with negative count failed, get %v, %v", members, err)
+	}
+
+	members, err = key.SPopN(ctx, 2)
+	if err != nil || len(members) != 2 {
+		t.Errorf("spop count failed, get %v, %v", members, err)
+	}
+
+	_, err = key.SPop(ctx)
+	if err != nil {
+		t.Errorf("spop failed due to %v", err)
+	}
+
+	_, err = key.SPop(ctx)
+	if err != redis.Nil {
+		t.Errorf("spop empty set should return redis.Nil, get %v", err)
+	}
+}
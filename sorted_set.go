@@ -0,0 +1,591 @@
+package ro
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	sortedSetKeyPool = sync.Pool{
+		New: func() interface{} {
+			return &SortedSetKey{}
+		},
+	}
+	sortedSetParameterKeyPool = sync.Pool{
+		New: func() interface{} {
+			return &SortedSetParameterKey{}
+		},
+	}
+)
+
+// ZRangeQuery is the range of ZRANGE, Start and Stop are ranks by default,
+// scores such as "(1" or "+inf" with ByScore, or lex such as "[a" or "-" with ByLex.
+// Start is the min and Stop is the max of ByScore and ByLex queries even with Rev,
+// Offset and Count limit their result.
+type ZRangeQuery struct {
+	Start   interface{}
+	Stop    interface{}
+	ByScore bool
+	ByLex   bool
+	Rev     bool
+	Offset  int64
+	Count   int64
+}
+
+type ZStoreOption struct {
+	// Weights multiply the scores of the source keys in order.
+	Weights []float64
+	// Aggregate can be SUM, MIN or MAX.
+	Aggregate string
+}
+
+type SortedSetKey struct {
+	*Key
+}
+
+func NewSortedSetKey(key string) *SortedSetKey {
+	k := sortedSetKeyPool.Get().(*SortedSetKey)
+	k.Key = NewKey(key)
+	return k
+}
+
+func (k SortedSetKey) ZAdd(ctx context.Context, members ...redis.Z) (int64, error) {
+	return k.ZAddArgs(ctx, redis.ZAddArgs{Members: members})
+}
+
+// ZAddArgs adds members with NX, XX, GT, LT and CH options, it returns the added count, or the changed count with CH.
+func (k SortedSetKey) ZAddArgs(ctx context.Context, args redis.ZAddArgs) (int64, error) {
+	if len(args.Members) == 0 {
+		return 0, nil
+	}
+
+	start := time.Now()
+	count, err := MustGetRedis(ctx).ZAddArgs(ctx, k.key, args).Result()
+	if err != nil {
+		log.Warn(ctx, "zadd failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int("members", len(args.Members)),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "zadd successfully",
+		log.String("key", k.key),
+		log.Int("members", len(args.Members)),
+		log.Int64("count", count),
+		log.Duration("duration", time.Since(start)))
+
+	return count, nil
+}
+
+// ZAddIncr increases the score of one member with ZADD INCR, it returns redis.Nil if aborted by NX, XX, GT or LT.
+func (k SortedSetKey) ZAddIncr(ctx context.Context, args redis.ZAddArgs) (float64, error) {
+	start := time.Now()
+	score, err := MustGetRedis(ctx).ZAddArgsIncr(ctx, k.key, args).Result()
+	if err != nil {
+		log.Warn(ctx, "zadd incr failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Any("args", args),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "zadd incr successfully",
+		log.String("key", k.key),
+		log.Any("args", args),
+		log.Float64("score", score),
+		log.Duration("duration", time.Since(start)))
+
+	return score, nil
+}
+
+func (k SortedSetKey) ZIncrBy(ctx context.Context, member string, increment float64) (float64, error) {
+	start := time.Now()
+	score, err := MustGetRedis(ctx).ZIncrBy(ctx, k.key, increment, member).Result()
+	if err != nil {
+		log.Warn(ctx, "zincrby failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("member", member),
+			log.Float64("increment", increment),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "zincrby successfully",
+		log.String("key", k.key),
+		log.String("member", member),
+		log.Float64("increment", increment),
+		log.Float64("score", score),
+		log.Duration("duration", time.Since(start)))
+
+	return score, nil
+}
+
+// ZScore returns redis.Nil if member does not exist.
+func (k SortedSetKey) ZScore(ctx context.Context, member string) (float64, error) {
+	start := time.Now()
+	score, err := MustGetRedis(ctx).ZScore(ctx, k.key, member).Result()
+	if err != nil {
+		log.Warn(ctx, "zscore failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("member", member),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "zscore successfully",
+		log.String("key", k.key),
+		log.String("member", member),
+		log.Float64("score", score),
+		log.Duration("duration", time.Since(start)))
+
+	return score, nil
+}
+
+// ZMScore returns the scores of the existing members.
+func (k SortedSetKey) ZMScore(ctx context.Context, members ...string) (map[string]float64, error) {
+	if len(members) == 0 {
+		return map[string]float64{}, nil
+	}
+
+	start := time.Now()
+	args := make([]interface{}, 0, len(members)+2)
+	args = append(args, "zmscore", k.key)
+	args = append(args, stringsToInterfaces(members)...)
+
+	values, err := MustGetRedis(ctx).Do(ctx, args...).Slice()
+	if err == nil && len(values) != len(members) {
+		err = ErrInvalidResultCount
+	}
+
+	scores := make(map[string]float64, len(members))
+	for index, value := range values {
+		if err != nil {
+			break
+		}
+
+		switch v := value.(type) {
+		case nil:
+			continue
+		case string:
+			scores[members[index]], err = strconv.ParseFloat(v, 64)
+		case float64:
+			scores[members[index]] = v
+		default:
+			err = ErrInvalidEncodedValue
+		}
+	}
+	if err != nil {
+		log.Warn(ctx, "zmscore failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Strings("members", members),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, "zmscore successfully",
+		log.String("key", k.key),
+		log.Strings("members", members),
+		log.Any("scores", scores),
+		log.Duration("duration", time.Since(start)))
+
+	return scores, nil
+}
+
+// ZRank returns the rank from the lowest score, it returns redis.Nil if member does not exist.
+func (k SortedSetKey) ZRank(ctx context.Context, member string) (int64, error) {
+	return k.rank(ctx, "zrank", member, MustGetRedis(ctx).ZRank)
+}
+
+// ZRevRank returns the rank from the highest score, it returns redis.Nil if member does not exist.
+func (k SortedSetKey) ZRevRank(ctx context.Context, member string) (int64, error) {
+	return k.rank(ctx, "zrevrank", member, MustGetRedis(ctx).ZRevRank)
+}
+
+func (k SortedSetKey) rank(ctx context.Context, operation, member string, fn func(context.Context, string, string) *redis.IntCmd) (int64, error) {
+	start := time.Now()
+	rank, err := fn(ctx, k.key, member).Result()
+	if err != nil {
+		log.Warn(ctx, operation+" failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("member", member),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, operation+" successfully",
+		log.String("key", k.key),
+		log.String("member", member),
+		log.Int64("rank", rank),
+		log.Duration("duration", time.Since(start)))
+
+	return rank, nil
+}
+
+func (k SortedSetKey) ZRankWithScore(ctx context.Context, member string) (redis.RankScore, error) {
+	return k.rankWithScore(ctx, "zrank withscore", member, MustGetRedis(ctx).ZRankWithScore)
+}
+
+func (k SortedSetKey) ZRevRankWithScore(ctx context.Context, member string) (redis.RankScore, error) {
+	return k.rankWithScore(ctx, "zrevrank withscore", member, MustGetRedis(ctx).ZRevRankWithScore)
+}
+
+func (k SortedSetKey) rankWithScore(ctx context.Context, operation, member string, fn func(context.Context, string, string) *redis.RankWithScoreCmd) (redis.RankScore, error) {
+	start := time.Now()
+	rank, err := fn(ctx, k.key, member).Result()
+	if err != nil {
+		log.Warn(ctx, operation+" failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("member", member),
+			log.Duration("duration", time.Since(start)))
+		return rank, err
+	}
+
+	log.Debug(ctx, operation+" successfully",
+		log.String("key", k.key),
+		log.String("member", member),
+		log.Int64("rank", rank.Rank),
+		log.Float64("score", rank.Score),
+		log.Duration("duration", time.Since(start)))
+
+	return rank, nil
+}
+
+func (k SortedSetKey) rangeArgs(query ZRangeQuery) redis.ZRangeArgs {
+	return redis.ZRangeArgs{
+		Key:     k.key,
+		Start:   query.Start,
+		Stop:    query.Stop,
+		ByScore: query.ByScore,
+		ByLex:   query.ByLex,
+		Rev:     query.Rev,
+		Offset:  query.Offset,
+		Count:   query.Count,
+	}
+}
+
+func (k SortedSetKey) ZRange(ctx context.Context, query ZRangeQuery) ([]string, error) {
+	start := time.Now()
+	members, err := MustGetRedis(ctx).ZRangeArgs(ctx, k.rangeArgs(query)).Result()
+	if err != nil {
+		log.Warn(ctx, "zrange failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Any("query", query),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, "zrange successfully",
+		log.String("key", k.key),
+		log.Any("query", query),
+		log.Int("count", len(members)),
+		log.Duration("duration", time.Since(start)))
+
+	return members, nil
+}
+
+func (k SortedSetKey) ZRangeWithScores(ctx context.Context, query ZRangeQuery) ([]redis.Z, error) {
+	start := time.Now()
+	members, err := MustGetRedis(ctx).ZRangeArgsWithScores(ctx, k.rangeArgs(query)).Result()
+	if err != nil {
+		log.Warn(ctx, "zrange withscores failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Any("query", query),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, "zrange withscores successfully",
+		log.String("key", k.key),
+		log.Any("query", query),
+		log.Int("count", len(members)),
+		log.Duration("duration", time.Since(start)))
+
+	return members, nil
+}
+
+func (k SortedSetKey) ZRem(ctx context.Context, members ...string) (int64, error) {
+	if len(members) == 0 {
+		return 0, nil
+	}
+
+	start := time.Now()
+	count, err := MustGetRedis(ctx).ZRem(ctx, k.key, stringsToInterfaces(members)...).Result()
+	if err != nil {
+		log.Warn(ctx, "zrem failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int("members", len(members)),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "zrem successfully",
+		log.String("key", k.key),
+		log.Int("members", len(members)),
+		log.Int64("count", count),
+		log.Duration("duration", time.Since(start)))
+
+	return count, nil
+}
+
+func (k SortedSetKey) ZRemRangeByRank(ctx context.Context, start, stop int64) (int64, error) {
+	return k.remRange(ctx, "zremrangebyrank", func() *redis.IntCmd {
+		return MustGetRedis(ctx).ZRemRangeByRank(ctx, k.key, start, stop)
+	}, log.Int64("start", start), log.Int64("stop", stop))
+}
+
+func (k SortedSetKey) ZRemRangeByScore(ctx context.Context, min, max string) (int64, error) {
+	return k.remRange(ctx, "zremrangebyscore", func() *redis.IntCmd {
+		return MustGetRedis(ctx).ZRemRangeByScore(ctx, k.key, min, max)
+	}, log.String("min", min), log.String("max", max))
+}
+
+func (k SortedSetKey) ZRemRangeByLex(ctx context.Context, min, max string) (int64, error) {
+	return k.remRange(ctx, "zremrangebylex", func() *redis.IntCmd {
+		return MustGetRedis(ctx).ZRemRangeByLex(ctx, k.key, min, max)
+	}, log.String("min", min), log.String("max", max))
+}
+
+func (k SortedSetKey) remRange(ctx context.Context, operation string, fn func() *redis.IntCmd, fields ...log.Field) (int64, error) {
+	start := time.Now()
+	count, err := fn().Result()
+	if err != nil {
+		log.Warn(ctx, operation+" failed", append(fields,
+			log.Err(err),
+			log.String("key", k.key),
+			log.Duration("duration", time.Since(start)))...)
+		return 0, err
+	}
+
+	log.Debug(ctx, operation+" successfully", append(fields,
+		log.String("key", k.key),
+		log.Int64("count", count),
+		log.Duration("duration", time.Since(start)))...)
+
+	return count, nil
+}
+
+func (k SortedSetKey) ZPopMin(ctx context.Context, count int64) ([]redis.Z, error) {
+	return k.pop(ctx, "zpopmin", count, MustGetRedis(ctx).ZPopMin)
+}
+
+func (k SortedSetKey) ZPopMax(ctx context.Context, count int64) ([]redis.Z, error) {
+	return k.pop(ctx, "zpopmax", count, MustGetRedis(ctx).ZPopMax)
+}
+
+func (k SortedSetKey) pop(ctx context.Context, operation string, count int64, fn func(context.Context, string, ...int64) *redis.ZSliceCmd) ([]redis.Z, error) {
+	start := time.Now()
+	members, err := fn(ctx, k.key, count).Result()
+	if err != nil {
+		log.Warn(ctx, operation+" failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int64("count", count),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, operation+" successfully",
+		log.String("key", k.key),
+		log.Int64("count", count),
+		log.Any("members", members),
+		log.Duration("duration", time.Since(start)))
+
+	return members, nil
+}
+
+// BZPopMin pops the lowest member from the first non-empty sorted set of k and others.
+// It returns ErrRecordNotFound when timeout, 0 timeout blocks until the ctx deadline if any.
+func (k SortedSetKey) BZPopMin(ctx context.Context, timeout time.Duration, others ...*SortedSetKey) (*redis.ZWithKey, error) {
+	return k.blockingPop(ctx, "bzpopmin", timeout, others, MustGetRedis(ctx).BZPopMin)
+}
+
+func (k SortedSetKey) BZPopMax(ctx context.Context, timeout time.Duration, others ...*SortedSetKey) (*redis.ZWithKey, error) {
+	return k.blockingPop(ctx, "bzpopmax", timeout, others, MustGetRedis(ctx).BZPopMax)
+}
+
+func (k SortedSetKey) blockingPop(ctx context.Context, operation string, timeout time.Duration, others []*SortedSetKey, fn func(context.Context, time.Duration, ...string) *redis.ZWithKeyCmd) (*redis.ZWithKey, error) {
+	keys := k.withOthers(others)
+	timeout, err := blockingTimeout(ctx, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	member, err := fn(ctx, timeout, keys...).Result()
+	if err == redis.Nil {
+		log.Debug(ctx, operation+" no member found",
+			log.Strings("keys", keys),
+			log.Duration("timeout", timeout),
+			log.Duration("duration", time.Since(start)))
+		return nil, ErrRecordNotFound
+	}
+
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+
+		log.Warn(ctx, operation+" failed",
+			log.Err(err),
+			log.Strings("keys", keys),
+			log.Duration("timeout", timeout),
+			log.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	log.Debug(ctx, operation+" successfully",
+		log.Strings("keys", keys),
+		log.Any("member", member),
+		log.Duration("duration", time.Since(start)))
+
+	return member, nil
+}
+
+func (k SortedSetKey) ZCard(ctx context.Context) (int64, error) {
+	start := time.Now()
+	count, err := MustGetRedis(ctx).ZCard(ctx, k.key).Result()
+	if err != nil {
+		log.Warn(ctx, "zcard failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "zcard successfully",
+		log.String("key", k.key),
+		log.Int64("count", count),
+		log.Duration("duration", time.Since(start)))
+
+	return count, nil
+}
+
+// ZCount counts members with scores between min and max, such as "(1" or "+inf".
+func (k SortedSetKey) ZCount(ctx context.Context, min, max string) (int64, error) {
+	start := time.Now()
+	count, err := MustGetRedis(ctx).ZCount(ctx, k.key, min, max).Result()
+	if err != nil {
+		log.Warn(ctx, "zcount failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("min", min),
+			log.String("max", max),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "zcount successfully",
+		log.String("key", k.key),
+		log.String("min", min),
+		log.String("max", max),
+		log.Int64("count", count),
+		log.Duration("duration", time.Since(start)))
+
+	return count, nil
+}
+
+// ZUnionStore stores the union of k and others into destination, expiration 0 keeps destination persistent.
+func (k SortedSetKey) ZUnionStore(ctx context.Context, destination *SortedSetKey, expiration time.Duration, option *ZStoreOption, others ...*SortedSetKey) (int64, error) {
+	store := k.store(option, others)
+	return k.combineStore(ctx, "zunionstore", destination, expiration, store.Keys, func(pipe redis.Pipeliner) *redis.IntCmd {
+		return pipe.ZUnionStore(ctx, destination.key, store)
+	})
+}
+
+func (k SortedSetKey) ZInterStore(ctx context.Context, destination *SortedSetKey, expiration time.Duration, option *ZStoreOption, others ...*SortedSetKey) (int64, error) {
+	store := k.store(option, others)
+	return k.combineStore(ctx, "zinterstore", destination, expiration, store.Keys, func(pipe redis.Pipeliner) *redis.IntCmd {
+		return pipe.ZInterStore(ctx, destination.key, store)
+	})
+}
+
+// ZDiffStore stores the members of k which are not in any of others, with the scores of k.
+func (k SortedSetKey) ZDiffStore(ctx context.Context, destination *SortedSetKey, expiration time.Duration, others ...*SortedSetKey) (int64, error) {
+	keys := k.withOthers(others)
+	return k.combineStore(ctx, "zdiffstore", destination, expiration, keys, func(pipe redis.Pipeliner) *redis.IntCmd {
+		return pipe.ZDiffStore(ctx, destination.key, keys...)
+	})
+}
+
+func (k SortedSetKey) store(option *ZStoreOption, others []*SortedSetKey) *redis.ZStore {
+	store := &redis.ZStore{Keys: k.withOthers(others)}
+	if option != nil {
+		store.Weights = option.Weights
+		store.Aggregate = option.Aggregate
+	}
+
+	return store
+}
+
+func (k SortedSetKey) combineStore(ctx context.Context, operation string, destination *SortedSetKey, expiration time.Duration, keys []string, fn func(redis.Pipeliner) *redis.IntCmd) (int64, error) {
+	start := time.Now()
+
+	var cmd *redis.IntCmd
+	_, err := MustGetRedis(ctx).TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		cmd = fn(pipe)
+		if expiration > 0 {
+			pipe.PExpire(ctx, destination.key, expiration)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Warn(ctx, operation+" failed",
+			log.Err(err),
+			log.Strings("keys", keys),
+			log.String("destination", destination.key),
+			log.Duration("expiration", expiration),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	count := cmd.Val()
+	log.Debug(ctx, operation+" successfully",
+		log.Strings("keys", keys),
+		log.String("destination", destination.key),
+		log.Duration("expiration", expiration),
+		log.Int64("count", count),
+		log.Duration("duration", time.Since(start)))
+
+	return count, nil
+}
+
+func (k SortedSetKey) withOthers(others []*SortedSetKey) []string {
+	keys := make([]string, 0, len(others)+1)
+	keys = append(keys, k.key)
+	for _, other := range others {
+		keys = append(keys, other.key)
+	}
+
+	return keys
+}
+
+type SortedSetParameterKey struct {
+	*ParameterKey
+}
+
+func NewSortedSetParameterKey(pattern string) *SortedSetParameterKey {
+	k := sortedSetParameterKeyPool.Get().(*SortedSetParameterKey)
+	k.ParameterKey = NewParameterKey(pattern)
+	return k
+}
+
+func (k SortedSetParameterKey) Param(parameters ...interface{}) *SortedSetKey {
+	return NewSortedSetKey(fmt.Sprintf(k.pattern, parameters...))
+}
@@ -0,0 +1,219 @@
+package ro
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestSortedSetKey_Add(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewSortedSetKey("zset:add")
+	defer key.Del(ctx)
+
+	count, err := key.ZAdd(ctx, redis.Z{Member: "a", Score: 1}, redis.Z{Member: "b", Score: 2})
+	if err != nil || count != 2 {
+		t.Errorf("zadd failed, get %d, %v", count, err)
+	}
+
+	count, err = key.ZAddArgs(ctx, redis.ZAddArgs{NX: true, Members: []redis.Z{{Member: "a", Score: 10}, {Member: "c", Score: 3}}})
+	if err != nil || count != 1 {
+		t.Errorf("zadd nx failed, get %d, %v", count, err)
+	}
+
+	count, err = key.ZAddArgs(ctx, redis.ZAddArgs{GT: true, Ch: true, Members: []redis.Z{{Member: "a", Score: 0}, {Member: "b", Score: 5}}})
+	if err != nil || count != 1 {
+		t.Errorf("zadd gt ch failed, get %d, %v", count, err)
+	}
+
+	score, err := key.ZAddIncr(ctx, redis.ZAddArgs{XX: true, Members: []redis.Z{{Member: "a", Score: 2}}})
+	if err != nil || score != 3 {
+		t.Errorf("zadd incr failed, get %v, %v", score, err)
+	}
+
+	_, err = key.ZAddIncr(ctx, redis.ZAddArgs{XX: true, Members: []redis.Z{{Member: "z", Score: 2}}})
+	if err != redis.Nil {
+		t.Errorf("zadd incr xx on missing member should return redis.Nil, get %v", err)
+	}
+
+	score, err = key.ZIncrBy(ctx, "c", 0.5)
+	if err != nil || score != 3.5 {
+		t.Errorf("zincrby failed, get %v, %v", score, err)
+	}
+
+	score, err = key.ZScore(ctx, "b")
+	if err != nil || score != 5 {
+		t.Errorf("zscore failed, get %v, %v", score, err)
+	}
+
+	scores, err := key.ZMScore(ctx, "a", "z", "c")
+	if err != nil || !reflect.DeepEqual(scores, map[string]float64{"a": 3, "c": 3.5}) {
+		t.Errorf("zmscore failed, get %v, %v", scores, err)
+	}
+
+	rank, err := key.ZRank(ctx, "c")
+	if err != nil || rank != 1 {
+		t.Errorf("zrank failed, get %d, %v", rank, err)
+	}
+
+	rank, err = key.ZRevRank(ctx, "b")
+	if err != nil || rank != 0 {
+		t.Errorf("zrevrank failed, get %d, %v", rank, err)
+	}
+
+	_, err = key.ZRank(ctx, "z")
+	if err != redis.Nil {
+		t.Errorf("zrank of missing member should return redis.Nil, get %v", err)
+	}
+
+	rankScore, err := key.ZRevRankWithScore(ctx, "a")
+	if err != nil || rankScore.Rank != 2 || rankScore.Score != 3 {
+		t.Errorf("zrevrank withscore failed, get %+v, %v", rankScore, err)
+	}
+}
+
+func TestSortedSetKey_Range(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewSortedSetParameterKey("zset:range:%d").Param(1)
+	defer key.Del(ctx)
+
+	_, _ = key.ZAdd(ctx, redis.Z{Member: "a", Score: 1}, redis.Z{Member: "b", Score: 2}, redis.Z{Member: "c", Score: 3}, redis.Z{Member: "d", Score: 4})
+
+	members, err := key.ZRange(ctx, ZRangeQuery{Start: 0, Stop: 1, Rev: true})
+	if err != nil || !reflect.DeepEqual(members, []string{"d", "c"}) {
+		t.Errorf("zrange by rank failed, get %v, %v", members, err)
+	}
+
+	members, err = key.ZRange(ctx, ZRangeQuery{Start: "(1", Stop: "+inf", ByScore: true, Offset: 1, Count: 2})
+	if err != nil || !reflect.DeepEqual(members, []string{"c", "d"}) {
+		t.Errorf("zrange by score failed, get %v, %v", members, err)
+	}
+
+	zs, err := key.ZRangeWithScores(ctx, ZRangeQuery{Start: 1, Stop: 3, ByScore: true, Rev: true})
+	if err != nil || !reflect.DeepEqual(zs, []redis.Z{{Member: "c", Score: 3}, {Member: "b", Score: 2}, {Member: "a", Score: 1}}) {
+		t.Errorf("zrange rev by score with scores failed, get %v, %v", zs, err)
+	}
+
+	count, err := key.ZCount(ctx, "2", "(4")
+	if err != nil || count != 2 {
+		t.Errorf("zcount failed, get %d, %v", count, err)
+	}
+
+	count, err = key.ZRemRangeByScore(ctx, "-inf", "1")
+	if err != nil || count != 1 {
+		t.Errorf("zremrangebyscore failed, get %d, %v", count, err)
+	}
+
+	count, err = key.ZRemRangeByRank(ctx, -1, -1)
+	if err != nil || count != 1 {
+		t.Errorf("zremrangebyrank failed, get %d, %v", count, err)
+	}
+
+	count, err = key.ZRem(ctx, "b", "z")
+	if err != nil || count != 1 {
+		t.Errorf("zrem failed, get %d, %v", count, err)
+	}
+
+	count, err = key.ZCard(ctx)
+	if err != nil || count != 1 {
+		t.Errorf("zcard failed, get %d, %v", count, err)
+	}
+}
+
+func TestSortedSetKey_Lex(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewSortedSetKey("zset:lex")
+	defer key.Del(ctx)
+
+	_, _ = key.ZAdd(ctx, redis.Z{Member: "apple"}, redis.Z{Member: "banana"}, redis.Z{Member: "cherry"})
+
+	members, err := key.ZRange(ctx, ZRangeQuery{Start: "[b", Stop: "+", ByLex: true})
+	if err != nil || !reflect.DeepEqual(members, []string{"banana", "cherry"}) {
+		t.Errorf("zrange by lex failed, get %v, %v", members, err)
+	}
+
+	count, err := key.ZRemRangeByLex(ctx, "-", "(c")
+	if err != nil || count != 2 {
+		t.Errorf("zremrangebylex failed, get %d, %v", count, err)
+	}
+}
+
+func TestSortedSetKey_Pop(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewSortedSetKey("zset:pop")
+	empty := NewSortedSetKey("zset:pop:empty")
+	defer key.Del(ctx)
+
+	_, _ = key.ZAdd(ctx, redis.Z{Member: "a", Score: 1}, redis.Z{Member: "b", Score: 2}, redis.Z{Member: "c", Score: 3})
+
+	members, err := key.ZPopMin(ctx, 1)
+	if err != nil || !reflect.DeepEqual(members, []redis.Z{{Member: "a", Score: 1}}) {
+		t.Errorf("zpopmin failed, get %v, %v", members, err)
+	}
+
+	members, err = key.ZPopMax(ctx, 1)
+	if err != nil || !reflect.DeepEqual(members, []redis.Z{{Member: "c", Score: 3}}) {
+		t.Errorf("zpopmax failed, get %v, %v", members, err)
+	}
+
+	member, err := empty.BZPopMax(ctx, time.Second, key)
+	if err != nil || member.Key != "zset:pop" || member.Member != "b" {
+		t.Errorf("bzpopmax failed, get %+v, %v", member, err)
+	}
+
+	_, err = key.BZPopMin(ctx, time.Second)
+	if err != ErrRecordNotFound {
+		t.Errorf("bzpopmin on empty sorted set should return ErrRecordNotFound, get %v", err)
+	}
+}
+
+func TestSortedSetKey_Store(t *testing.T) {
+	ctx := context.Background()
+
+	key1 := NewSortedSetKey("zset:store:1")
+	key2 := NewSortedSetKey("zset:store:2")
+	destination := NewSortedSetKey("zset:store:destination")
+	defer key1.Del(ctx)
+	defer key2.Del(ctx)
+	defer destination.Del(ctx)
+
+	_, _ = key1.ZAdd(ctx, redis.Z{Member: "a", Score: 1}, redis.Z{Member: "b", Score: 2})
+	_, _ = key2.ZAdd(ctx, redis.Z{Member: "b", Score: 10}, redis.Z{Member: "c", Score: 20})
+
+	count, err := key1.ZUnionStore(ctx, destination, time.Minute, &ZStoreOption{Weights: []float64{2, 1}}, key2)
+	if err != nil || count != 3 {
+		t.Errorf("zunionstore failed, get %d, %v", count, err)
+	}
+
+	zs, err := destination.ZRangeWithScores(ctx, ZRangeQuery{Start: 0, Stop: -1})
+	if err != nil || !reflect.DeepEqual(zs, []redis.Z{{Member: "a", Score: 2}, {Member: "b", Score: 14}, {Member: "c", Score: 20}}) {
+		t.Errorf("zunionstore with weights failed, get %v, %v", zs, err)
+	}
+
+	ttl, err := destination.TTL(ctx)
+	if err != nil || ttl <= 0 {
+		t.Errorf("zunionstore should set ttl, get %v, %v", ttl, err)
+	}
+
+	count, err = key1.ZInterStore(ctx, destination, 0, &ZStoreOption{Aggregate: "MAX"}, key2)
+	if err != nil || count != 1 {
+		t.Errorf("zinterstore failed, get %d, %v", count, err)
+	}
+
+	score, err := destination.ZScore(ctx, "b")
+	if err != nil || score != 10 {
+		t.Errorf("zinterstore aggregate failed, get %v, %v", score, err)
+	}
+
+	count, err = key1.ZDiffStore(ctx, destination, 0, key2)
+	if err != nil || count != 1 {
+		t.Errorf("zdiffstore failed, get %d, %v", count, err)
+	}
+}
@@ -34,12 +34,12 @@ func NewStreamKey(key string) *StreamKey {
 }
 
 type StreamParameterKey struct {
-	pattern string
+	*ParameterKey
 }
 
 func NewStreamParameterKey(pattern string) *StreamParameterKey {
 	k := streamParameterKeyPool.Get().(*StreamParameterKey)
-	k.pattern = pattern
+	k.ParameterKey = NewParameterKey(pattern)
 	return k
 }
 
@@ -74,6 +74,47 @@ func (s StreamKey) XAdd(ctx context.Context, id string, maxLen, limit int64, val
 	return messageID, nil
 }
 
+// XAddBatch adds messages with auto generated ids in pipelines, ids of failed messages are empty.
+// The pipelines are sent one by one, so the entries keep the order of messages.
+func (s StreamKey) XAddBatch(ctx context.Context, maxLen, limit int64, messages []map[string]interface{}) ([]string, error) {
+	if len(messages) == 0 {
+		return nil, nil
+	}
+
+	start := time.Now()
+	ids := make([]string, len(messages))
+	count, err := runChunks(ctx, len(messages), 1, getChunkOption().ChunkSize, 1, nil,
+		func(ctx context.Context, pipe redis.Pipeliner, from, to int) (redis.Cmder, func() int64) {
+			cmd := pipe.XAdd(ctx, &redis.XAddArgs{
+				Stream: s.key,
+				MaxLen: maxLen,
+				Limit:  limit,
+				ID:     "*",
+				Values: messages[from],
+			})
+			return cmd, func() int64 {
+				ids[from] = cmd.Val()
+				return 1
+			}
+		})
+	if err != nil {
+		log.Warn(ctx, "xadd batch failed",
+			log.Err(err),
+			log.String("key", s.key),
+			log.Int("total", len(messages)),
+			log.Int64("count", count),
+			log.Duration("duration", time.Since(start)))
+		return ids, err
+	}
+
+	log.Debug(ctx, "xadd batch successfully",
+		log.String("key", s.key),
+		log.Int("total", len(messages)),
+		log.Duration("duration", time.Since(start)))
+
+	return ids, nil
+}
+
 func (s StreamKey) XAddToEnd(ctx context.Context, values ...string) (string, error) {
 	if len(values) < 2 || len(values)%2 != 0 {
 		return "", ErrBadRequest
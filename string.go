@@ -2,13 +2,13 @@ package ro
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
 )
 
 var (
@@ -22,6 +22,30 @@ var (
 			return &StringParameterKey{}
 		},
 	}
+
+	// KEYS[1] counter, ARGV[1] increment, ARGV[2] expiration in milliseconds.
+	// The expiration is only applied when the counter has none, e.g. it was just created.
+	increaseByWithExpirationScript = redis.NewScript(`
+local value = redis.call('INCRBY', KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) > 0 and redis.call('PTTL', KEYS[1]) == -1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return value
+`)
+
+	// KEYS[1] counter, ARGV[1] increment, ARGV[2] ceiling, ARGV[3] expiration in milliseconds.
+	increaseByBoundedScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+if current + tonumber(ARGV[1]) > tonumber(ARGV[2]) then
+	return {0, current}
+end
+
+local value = redis.call('INCRBY', KEYS[1], ARGV[1])
+if tonumber(ARGV[3]) > 0 and redis.call('PTTL', KEYS[1]) == -1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+end
+return {1, value}
+`)
 )
 
 type StringKey struct {
@@ -101,7 +125,7 @@ func (k StringKey) GetObject(ctx context.Context, obj interface{}) error {
 		return err
 	}
 
-	err = json.Unmarshal([]byte(value), obj)
+	upgraded, err := unmarshalObject(value, obj)
 	if err != nil {
 		log.Warn(ctx, "get object failed",
 			log.Err(err),
@@ -110,6 +134,10 @@ func (k StringKey) GetObject(ctx context.Context, obj interface{}) error {
 		return err
 	}
 
+	if upgraded != "" {
+		k.writeBackObject(ctx, value, upgraded)
+	}
+
 	return nil
 }
 
@@ -143,8 +171,8 @@ func (k StringKey) SetInt64(ctx context.Context, value int64, expiration time.Du
 	return k.Set(ctx, strconv.FormatInt(value, 10), expiration)
 }
 
-func (k StringKey) SetObject(ctx context.Context, obj interface{}, expiration time.Duration) error {
-	buffer, err := json.Marshal(obj)
+func (k StringKey) SetObject(ctx context.Context, obj interface{}, expiration time.Duration, tags ...string) error {
+	buffer, err := marshalObject(obj)
 	if err != nil {
 		log.Warn(ctx, "marshal object failed",
 			log.Err(err),
@@ -152,7 +180,7 @@ func (k StringKey) SetObject(ctx context.Context, obj interface{}, expiration ti
 		return err
 	}
 
-	return k.Set(ctx, string(buffer), expiration)
+	return k.SetWithTags(ctx, string(buffer), expiration, tags...)
 }
 
 func (k StringKey) SetNX(ctx context.Context, value string, expiration time.Duration) (bool, error) {
@@ -273,13 +301,149 @@ func (k StringKey) IncreaseBy(ctx context.Context, value int64) (int64, error) {
 	return newValue, nil
 }
 
+func (k StringKey) IncreaseWithExpiration(ctx context.Context, expiration time.Duration) (int64, error) {
+	return k.IncreaseByWithExpiration(ctx, 1, expiration)
+}
+
+func (k StringKey) IncreaseByWithExpiration(ctx context.Context, value int64, expiration time.Duration) (int64, error) {
+	start := time.Now()
+	newValue, err := increaseByWithExpirationScript.Run(ctx, MustGetRedis(ctx), []string{k.key}, value, expiration.Milliseconds()).Int64()
+	if err != nil {
+		log.Warn(ctx, "increase by value with expiration failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int64("value", value),
+			log.Duration("expiration", expiration),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "increase by value with expiration successfully",
+		log.String("key", k.key),
+		log.Int64("value", value),
+		log.Int64("newValue", newValue),
+		log.Duration("expiration", expiration),
+		log.Duration("duration", time.Since(start)))
+
+	return newValue, nil
+}
+
+// IncreaseByInWindow expires the counter at the end of the window in location, UTC if nil.
+func (k StringKey) IncreaseByInWindow(ctx context.Context, value int64, window CalendarWindow, location *time.Location) (int64, error) {
+	if location == nil {
+		location = time.UTC
+	}
+
+	return k.IncreaseByWithExpiration(ctx, value, windowExpiration(window, time.Now().In(location)))
+}
+
+// windowExpiration rounds up to milliseconds, since 0 means no expiration to the script.
+func windowExpiration(window CalendarWindow, now time.Time) time.Duration {
+	return (window.End(now).Sub(now) + time.Millisecond - 1).Truncate(time.Millisecond)
+}
+
+func (k StringKey) IncreaseByBounded(ctx context.Context, value, ceiling int64, expiration time.Duration) (int64, bool, error) {
+	start := time.Now()
+	values, err := increaseByBoundedScript.Run(ctx, MustGetRedis(ctx), []string{k.key}, value, ceiling, expiration.Milliseconds()).Int64Slice()
+	if err != nil {
+		log.Warn(ctx, "bounded increase by value failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int64("value", value),
+			log.Int64("ceiling", ceiling),
+			log.Duration("expiration", expiration),
+			log.Duration("duration", time.Since(start)))
+		return 0, false, err
+	}
+
+	if len(values) != 2 {
+		log.Warn(ctx, "invalid bounded increase result",
+			log.String("key", k.key),
+			log.Int64s("values", values),
+			log.Duration("duration", time.Since(start)))
+		return 0, false, ErrInvalidResultCount
+	}
+
+	increased := values[0] == 1
+	log.Debug(ctx, "bounded increase by value finished",
+		log.String("key", k.key),
+		log.Int64("value", value),
+		log.Int64("ceiling", ceiling),
+		log.Int64("newValue", values[1]),
+		log.Bool("increased", increased),
+		log.Duration("duration", time.Since(start)))
+
+	return values[1], increased, nil
+}
+
+func (k StringKey) IncreaseByFloat(ctx context.Context, value float64) (float64, error) {
+	start := time.Now()
+	newValue, err := MustGetRedis(ctx).IncrByFloat(ctx, k.key, value).Result()
+	if err != nil {
+		log.Warn(ctx, "increase by float value failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Float64("value", value),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "increase by float value successfully",
+		log.String("key", k.key),
+		log.Float64("value", value),
+		log.Float64("newValue", newValue),
+		log.Duration("duration", time.Since(start)))
+
+	return newValue, nil
+}
+
+func (k StringKey) Decrease(ctx context.Context) (int64, error) {
+	start := time.Now()
+	newValue, err := MustGetRedis(ctx).Decr(ctx, k.key).Result()
+	if err != nil {
+		log.Warn(ctx, "decrease value failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "decrease value successfully",
+		log.String("key", k.key),
+		log.Int64("newValue", newValue),
+		log.Duration("duration", time.Since(start)))
+
+	return newValue, nil
+}
+
+func (k StringKey) DecreaseBy(ctx context.Context, value int64) (int64, error) {
+	start := time.Now()
+	newValue, err := MustGetRedis(ctx).DecrBy(ctx, k.key, value).Result()
+	if err != nil {
+		log.Warn(ctx, "decrease by value failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Int64("value", value),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "decrease by value successfully",
+		log.String("key", k.key),
+		log.Int64("value", value),
+		log.Int64("newValue", newValue),
+		log.Duration("duration", time.Since(start)))
+
+	return newValue, nil
+}
+
 type StringParameterKey struct {
-	pattern string
+	*ParameterKey
 }
 
 func NewStringParameterKey(pattern string) *StringParameterKey {
 	k := stringParameterKeyPool.Get().(*StringParameterKey)
-	k.pattern = pattern
+	k.ParameterKey = NewParameterKey(pattern)
 	return k
 }
 
@@ -180,3 +180,101 @@ func TestStringKey_GetLocker(t *testing.T) {
 		t.Errorf("get unexpect result, want 1, get %d", total)
 	}
 }
+
+func TestStringKey_IncreaseByWithExpiration(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewStringKey("test6")
+	defer key.Del(ctx)
+
+	for index := int64(1); index <= 3; index++ {
+		value, err := key.IncreaseByWithExpiration(ctx, 2, time.Minute)
+		if err != nil {
+			t.Errorf("increase by with expiration failed due to %v", err)
+		}
+
+		if value != index*2 {
+			t.Errorf("get unexpect value, want %d, get %d", index*2, value)
+		}
+	}
+
+	ttl, err := key.TTL(ctx)
+	if err != nil {
+		t.Errorf("get ttl failed due to %v", err)
+	}
+
+	if ttl <= 0 || ttl > time.Minute {
+		t.Errorf("get unexpect ttl %v", ttl)
+	}
+
+	value, err := key.DecreaseBy(ctx, 4)
+	if err != nil {
+		t.Errorf("decrease by failed due to %v", err)
+	}
+
+	if value != 2 {
+		t.Errorf("get unexpect value, want 2, get %d", value)
+	}
+}
+
+func TestStringKey_IncreaseByBounded(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewStringKey("test7")
+	defer key.Del(ctx)
+
+	tests := []struct {
+		name          string
+		value         int64
+		wantValue     int64
+		wantIncreased bool
+	}{
+		{name: "1", value: 3, wantValue: 3, wantIncreased: true},
+		{name: "2", value: 2, wantValue: 5, wantIncreased: true},
+		{name: "3", value: 1, wantValue: 5, wantIncreased: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, increased, err := key.IncreaseByBounded(ctx, tt.value, 5, time.Minute)
+			if err != nil {
+				t.Errorf("StringKey.IncreaseByBounded() error = %v", err)
+				return
+			}
+			if value != tt.wantValue || increased != tt.wantIncreased {
+				t.Errorf("StringKey.IncreaseByBounded() = %d, %v, want %d, %v", value, increased, tt.wantValue, tt.wantIncreased)
+			}
+		})
+	}
+}
+
+func TestStringKey_IncreaseByInWindow(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewStringKey("test8")
+	defer key.Del(ctx)
+
+	_, err := key.IncreaseByInWindow(ctx, 1, CalendarHour, time.UTC)
+	if err != nil {
+		t.Errorf("increase by in window failed due to %v", err)
+	}
+
+	ttl, err := key.TTL(ctx)
+	if err != nil {
+		t.Errorf("get ttl failed due to %v", err)
+	}
+
+	if ttl <= 0 || ttl > time.Hour {
+		t.Errorf("get unexpect ttl %v", ttl)
+	}
+
+	_, err = key.IncreaseByInWindow(ctx, 1, CalendarDay, nil)
+	if err != nil {
+		t.Errorf("increase by in window with nil location failed due to %v", err)
+	}
+
+	end := time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)
+	expiration := windowExpiration(CalendarHour, end.Add(-500*time.Microsecond))
+	if expiration != time.Millisecond {
+		t.Errorf("expiration in the last millisecond should be rounded up, get %v", expiration)
+	}
+}
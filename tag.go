@@ -0,0 +1,157 @@
+package ro
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	TagKeyPattern = "ro:tag:%s"
+
+	// tagPruneSamples is how many members of a tag set are checked for
+	// naturally expired keys on every tagged write.
+	tagPruneSamples = 3
+
+	// KEYS[1] is the value key, KEYS[2..n] are the tag sets.
+	// ARGV[1] value, ARGV[2] expiration in milliseconds (0 means persistent), ARGV[3] prune samples.
+	// A tag set lives as long as its longest living member.
+	setWithTagsScript = redis.NewScript(`
+local expiration = tonumber(ARGV[2])
+if expiration > 0 then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', expiration)
+else
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+
+for index = 2, #KEYS do
+	local tag = KEYS[index]
+	local samples = redis.call('SRANDMEMBER', tag, tonumber(ARGV[3]))
+	for _, member in ipairs(samples) do
+		if member ~= KEYS[1] and redis.call('EXISTS', member) == 0 then
+			redis.call('SREM', tag, member)
+		end
+	end
+
+	local ttl = redis.call('PTTL', tag)
+	redis.call('SADD', tag, KEYS[1])
+	if expiration == 0 then
+		redis.call('PERSIST', tag)
+	elseif ttl == -2 or (ttl >= 0 and ttl < expiration) then
+		redis.call('PEXPIRE', tag, expiration)
+	end
+end
+
+return #KEYS - 1
+`)
+
+	// KEYS[1] is the tag set.
+	invalidateTagScript = redis.NewScript(`
+local members = redis.call('SMEMBERS', KEYS[1])
+local deleted = 0
+for index = 1, #members, 1000 do
+	deleted = deleted + redis.call('DEL', unpack(members, index, math.min(index + 999, #members)))
+end
+redis.call('DEL', KEYS[1])
+return deleted
+`)
+
+	// KEYS[1] is the tag set.
+	pruneTagScript = redis.NewScript(`
+local members = redis.call('SMEMBERS', KEYS[1])
+local removed = 0
+for _, member in ipairs(members) do
+	if redis.call('EXISTS', member) == 0 then
+		removed = removed + redis.call('SREM', KEYS[1], member)
+	end
+end
+return removed
+`)
+)
+
+func NewTagKey(tag string) *SetKey {
+	return NewSetKey(fmt.Sprintf(TagKeyPattern, tag))
+}
+
+func (k StringKey) SetWithTags(ctx context.Context, value string, expiration time.Duration, tags ...string) error {
+	if len(tags) == 0 {
+		return k.Set(ctx, value, expiration)
+	}
+
+	keys := make([]string, len(tags)+1)
+	keys[0] = k.key
+	for index, tag := range tags {
+		keys[index+1] = NewTagKey(tag).key
+	}
+
+	start := time.Now()
+	err := setWithTagsScript.Run(ctx, MustGetRedis(ctx), keys, value, expiration.Milliseconds(), tagPruneSamples).Err()
+	if err != nil {
+		log.Warn(ctx, "set key value with tags failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("value", value),
+			log.Duration("expiration", expiration),
+			log.Strings("tags", tags),
+			log.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	log.Debug(ctx, "set value with tags successfully",
+		log.String("key", k.key),
+		log.String("value", value),
+		log.Duration("expiration", expiration),
+		log.Strings("tags", tags),
+		log.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+func InvalidateTag(ctx context.Context, tag string) (int64, error) {
+	key := NewTagKey(tag).key
+
+	start := time.Now()
+	deleted, err := invalidateTagScript.Run(ctx, MustGetRedis(ctx), []string{key}).Int64()
+	if err != nil {
+		log.Warn(ctx, "invalidate tag failed",
+			log.Err(err),
+			log.String("tag", tag),
+			log.String("key", key),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "invalidate tag successfully",
+		log.String("tag", tag),
+		log.String("key", key),
+		log.Int64("deleted", deleted),
+		log.Duration("duration", time.Since(start)))
+
+	return deleted, nil
+}
+
+func PruneTag(ctx context.Context, tag string) (int64, error) {
+	key := NewTagKey(tag).key
+
+	start := time.Now()
+	removed, err := pruneTagScript.Run(ctx, MustGetRedis(ctx), []string{key}).Int64()
+	if err != nil {
+		log.Warn(ctx, "prune tag failed",
+			log.Err(err),
+			log.String("tag", tag),
+			log.String("key", key),
+			log.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	log.Debug(ctx, "prune tag successfully",
+		log.String("tag", tag),
+		log.String("key", key),
+		log.Int64("removed", removed),
+		log.Duration("duration", time.Since(start)))
+
+	return removed, nil
+}
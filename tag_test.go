@@ -0,0 +1,91 @@
+package ro
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestInvalidateTag(t *testing.T) {
+	ctx := context.Background()
+
+	key1 := NewStringKey("tag:test1")
+	defer key1.Del(ctx)
+
+	key2 := NewStringKey("tag:test2")
+	defer key2.Del(ctx)
+
+	key3 := NewStringKey("tag:test3")
+	defer key3.Del(ctx)
+
+	err := key1.SetObject(ctx, &testStruct{AAA: "a", BBB: 1}, time.Minute, "user:1")
+	if err != nil {
+		t.Errorf("set object with tags failed due to %v", err)
+	}
+
+	err = key2.SetObject(ctx, &testStruct{AAA: "b", BBB: 2}, 0, "user:1", "user:2")
+	if err != nil {
+		t.Errorf("set object with tags failed due to %v", err)
+	}
+
+	err = key3.SetObject(ctx, &testStruct{AAA: "c", BBB: 3}, time.Minute, "user:2")
+	if err != nil {
+		t.Errorf("set object with tags failed due to %v", err)
+	}
+
+	ttl, err := NewTagKey("user:1").TTL(ctx)
+	if err != nil {
+		t.Errorf("get tag ttl failed due to %v", err)
+	}
+
+	if ttl >= 0 {
+		t.Errorf("tag with persistent member should not expire, get ttl %v", ttl)
+	}
+
+	deleted, err := InvalidateTag(ctx, "user:1")
+	if err != nil {
+		t.Errorf("invalidate tag failed due to %v", err)
+	}
+
+	if deleted != 2 {
+		t.Errorf("invalidate tag deleted unexpect keys, want 2, get %d", deleted)
+	}
+
+	for _, key := range []*StringKey{key1, key2} {
+		exists, err := key.Exists(ctx)
+		if err != nil {
+			t.Errorf("check key exists failed due to %v", err)
+		}
+
+		if exists {
+			t.Errorf("key %s should be invalidated", key.Key.Key())
+		}
+	}
+
+	exists, err := key3.Exists(ctx)
+	if err != nil {
+		t.Errorf("check key exists failed due to %v", err)
+	}
+
+	if !exists {
+		t.Errorf("key %s should not be invalidated", key3.Key.Key())
+	}
+
+	removed, err := PruneTag(ctx, "user:2")
+	if err != nil {
+		t.Errorf("prune tag failed due to %v", err)
+	}
+
+	if removed != 1 {
+		t.Errorf("prune tag removed unexpect members, want 1, get %d", removed)
+	}
+
+	deleted, err = InvalidateTag(ctx, "user:2")
+	if err != nil {
+		t.Errorf("invalidate tag failed due to %v", err)
+	}
+
+	if deleted != 1 {
+		t.Errorf("invalidate tag deleted unexpect keys, want 1, get %d", deleted)
+	}
+}
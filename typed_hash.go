@@ -0,0 +1,179 @@
+package ro
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nzai/log"
+)
+
+type TypedHashKey[F comparable, V any] struct {
+	*Key
+	fieldCodec Codec[F]
+	valueCodec Codec[V]
+}
+
+func NewTypedHashKey[F comparable, V any](key string, fieldCodec Codec[F], valueCodec Codec[V]) *TypedHashKey[F, V] {
+	return &TypedHashKey[F, V]{
+		Key:        NewKey(key),
+		fieldCodec: fieldCodec,
+		valueCodec: valueCodec,
+	}
+}
+
+func (k TypedHashKey[F, V]) HGet(ctx context.Context, field F) (V, error) {
+	var value V
+	rawField, err := k.encodeField(ctx, field)
+	if err != nil {
+		return value, err
+	}
+
+	raw, err := HashSetKey{Key: k.Key}.HGet(ctx, rawField)
+	if err != nil {
+		return value, err
+	}
+
+	return k.decodeValue(ctx, raw)
+}
+
+func (k TypedHashKey[F, V]) HMGet(ctx context.Context, fields []F) (map[F]V, error) {
+	rawFields := make([]string, len(fields))
+	for index, field := range fields {
+		rawField, err := k.encodeField(ctx, field)
+		if err != nil {
+			return nil, err
+		}
+
+		rawFields[index] = rawField
+	}
+
+	values, err := HashSetKey{Key: k.Key}.HMGet(ctx, rawFields)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[F]V, len(values))
+	for index, rawField := range rawFields {
+		raw, found := values[rawField]
+		if !found {
+			continue
+		}
+
+		value, err := k.decodeValue(ctx, raw)
+		if err != nil {
+			return nil, err
+		}
+
+		result[fields[index]] = value
+	}
+
+	return result, nil
+}
+
+func (k TypedHashKey[F, V]) HGetAll(ctx context.Context) (map[F]V, error) {
+	values, err := HashSetKey{Key: k.Key}.HGetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[F]V, len(values))
+	for rawField, raw := range values {
+		field, err := k.fieldCodec.Decode([]byte(rawField))
+		if err != nil {
+			log.Warn(ctx, "decode field failed",
+				log.Err(err),
+				log.String("key", k.key),
+				log.String("field", rawField))
+			return nil, err
+		}
+
+		value, err := k.decodeValue(ctx, raw)
+		if err != nil {
+			return nil, err
+		}
+
+		result[field] = value
+	}
+
+	return result, nil
+}
+
+func (k TypedHashKey[F, V]) HSet(ctx context.Context, field F, value V) error {
+	rawField, err := k.encodeField(ctx, field)
+	if err != nil {
+		return err
+	}
+
+	buffer, err := k.valueCodec.Encode(value)
+	if err != nil {
+		log.Warn(ctx, "encode value failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Any("value", value))
+		return err
+	}
+
+	return HashSetKey{Key: k.Key}.HSet(ctx, rawField, string(buffer))
+}
+
+func (k TypedHashKey[F, V]) HDel(ctx context.Context, fields ...F) error {
+	rawFields := make([]string, len(fields))
+	for index, field := range fields {
+		rawField, err := k.encodeField(ctx, field)
+		if err != nil {
+			return err
+		}
+
+		rawFields[index] = rawField
+	}
+
+	return HashSetKey{Key: k.Key}.HDel(ctx, rawFields...)
+}
+
+func (k TypedHashKey[F, V]) HLen(ctx context.Context) (int64, error) {
+	return HashSetKey{Key: k.Key}.HLen(ctx)
+}
+
+func (k TypedHashKey[F, V]) encodeField(ctx context.Context, field F) (string, error) {
+	buffer, err := k.fieldCodec.Encode(field)
+	if err != nil {
+		log.Warn(ctx, "encode field failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Any("field", field))
+		return "", err
+	}
+
+	return string(buffer), nil
+}
+
+func (k TypedHashKey[F, V]) decodeValue(ctx context.Context, raw string) (V, error) {
+	value, err := k.valueCodec.Decode([]byte(raw))
+	if err != nil {
+		log.Warn(ctx, "decode value failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("value", raw))
+		return value, err
+	}
+
+	return value, nil
+}
+
+type TypedHashParameterKey[F comparable, V any] struct {
+	*ParameterKey
+	fieldCodec Codec[F]
+	valueCodec Codec[V]
+}
+
+func NewTypedHashParameterKey[F comparable, V any](pattern string, fieldCodec Codec[F], valueCodec Codec[V]) *TypedHashParameterKey[F, V] {
+	return &TypedHashParameterKey[F, V]{
+		ParameterKey: NewParameterKey(pattern),
+		fieldCodec:   fieldCodec,
+		valueCodec:   valueCodec,
+	}
+}
+
+func (k TypedHashParameterKey[F, V]) Param(parameters ...interface{}) *TypedHashKey[F, V] {
+	return NewTypedHashKey(fmt.Sprintf(k.pattern, parameters...), k.fieldCodec, k.valueCodec)
+}
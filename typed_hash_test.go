@@ -0,0 +1,45 @@
+package ro
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTypedHashKey_HSetAndHGetAll(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewTypedHashParameterKey[int64, time.Time]("typed:hash:%s", IntegerCodec[int64]{}, TimeCodec{}).Param("login")
+	defer key.Del(ctx)
+
+	want := map[int64]time.Time{
+		1: time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC),
+		2: time.Date(2024, 3, 11, 8, 0, 0, 0, time.UTC),
+	}
+
+	for field, value := range want {
+		err := key.HSet(ctx, field, value)
+		if err != nil {
+			t.Errorf("set typed field failed due to %v", err)
+		}
+	}
+
+	got, err := key.HGetAll(ctx)
+	if err != nil {
+		t.Errorf("get all typed fields failed due to %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("get all typed fields not equal, want %v, get %v", want, got)
+	}
+
+	got, err = key.HMGet(ctx, []int64{2, 3})
+	if err != nil {
+		t.Errorf("get typed fields failed due to %v", err)
+	}
+
+	if !reflect.DeepEqual(got, map[int64]time.Time{2: want[2]}) {
+		t.Errorf("get typed fields not equal, get %v", got)
+	}
+}
@@ -0,0 +1,112 @@
+package ro
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nzai/log"
+)
+
+type TypedSetKey[T any] struct {
+	*Key
+	codec Codec[T]
+}
+
+func NewTypedSetKey[T any](key string, codec Codec[T]) *TypedSetKey[T] {
+	return &TypedSetKey[T]{
+		Key:   NewKey(key),
+		codec: codec,
+	}
+}
+
+func (k TypedSetKey[T]) SAdd(ctx context.Context, members ...T) error {
+	rawMembers, err := k.encodeMembers(ctx, members)
+	if err != nil {
+		return err
+	}
+
+	return SetKey{Key: k.Key}.SAdd(ctx, rawMembers...)
+}
+
+func (k TypedSetKey[T]) SRem(ctx context.Context, members ...T) error {
+	rawMembers, err := k.encodeMembers(ctx, members)
+	if err != nil {
+		return err
+	}
+
+	return SetKey{Key: k.Key}.SRem(ctx, rawMembers...)
+}
+
+func (k TypedSetKey[T]) SIsMember(ctx context.Context, member T) (bool, error) {
+	rawMembers, err := k.encodeMembers(ctx, []T{member})
+	if err != nil {
+		return false, err
+	}
+
+	return SetKey{Key: k.Key}.SIsMember(ctx, rawMembers[0])
+}
+
+func (k TypedSetKey[T]) SMembers(ctx context.Context) ([]T, error) {
+	rawMembers, err := SetKey{Key: k.Key}.SMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return k.decodeMembers(ctx, rawMembers)
+}
+
+func (k TypedSetKey[T]) SCard(ctx context.Context) (int64, error) {
+	return SetKey{Key: k.Key}.SCard(ctx)
+}
+
+func (k TypedSetKey[T]) encodeMembers(ctx context.Context, members []T) ([]string, error) {
+	rawMembers := make([]string, len(members))
+	for index, member := range members {
+		buffer, err := k.codec.Encode(member)
+		if err != nil {
+			log.Warn(ctx, "encode member failed",
+				log.Err(err),
+				log.String("key", k.key),
+				log.Any("member", member))
+			return nil, err
+		}
+
+		rawMembers[index] = string(buffer)
+	}
+
+	return rawMembers, nil
+}
+
+func (k TypedSetKey[T]) decodeMembers(ctx context.Context, rawMembers []string) ([]T, error) {
+	members := make([]T, len(rawMembers))
+	for index, rawMember := range rawMembers {
+		member, err := k.codec.Decode([]byte(rawMember))
+		if err != nil {
+			log.Warn(ctx, "decode member failed",
+				log.Err(err),
+				log.String("key", k.key),
+				log.String("member", rawMember))
+			return nil, err
+		}
+
+		members[index] = member
+	}
+
+	return members, nil
+}
+
+type TypedSetParameterKey[T any] struct {
+	*ParameterKey
+	codec Codec[T]
+}
+
+func NewTypedSetParameterKey[T any](pattern string, codec Codec[T]) *TypedSetParameterKey[T] {
+	return &TypedSetParameterKey[T]{
+		ParameterKey: NewParameterKey(pattern),
+		codec:        codec,
+	}
+}
+
+func (k TypedSetParameterKey[T]) Param(parameters ...interface{}) *TypedSetKey[T] {
+	return NewTypedSetKey(fmt.Sprintf(k.pattern, parameters...), k.codec)
+}
@@ -0,0 +1,38 @@
+package ro
+
+import (
+	"context"
+	"sort"
+	"testing"
+)
+
+func TestTypedSetKey_SAddAndSMembers(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewTypedSetParameterKey[int]("typed:set:%d", IntegerCodec[int]{}).Param(1)
+	defer key.Del(ctx)
+
+	err := key.SAdd(ctx, 3, 1, 2, 3)
+	if err != nil {
+		t.Errorf("add typed members failed due to %v", err)
+	}
+
+	members, err := key.SMembers(ctx)
+	if err != nil {
+		t.Errorf("get typed members failed due to %v", err)
+	}
+
+	sort.Ints(members)
+	if len(members) != 3 || members[0] != 1 || members[2] != 3 {
+		t.Errorf("get unexpect typed members %v", members)
+	}
+
+	isMember, err := key.SIsMember(ctx, 2)
+	if err != nil {
+		t.Errorf("check typed member failed due to %v", err)
+	}
+
+	if !isMember {
+		t.Errorf("2 should be a member")
+	}
+}
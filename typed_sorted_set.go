@@ -0,0 +1,235 @@
+package ro
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nzai/log"
+	"github.com/redis/go-redis/v9"
+)
+
+type TypedZ[T any] struct {
+	Member T
+	Score  float64
+}
+
+type TypedSortedSetKey[T any] struct {
+	*Key
+	codec Codec[T]
+}
+
+func NewTypedSortedSetKey[T any](key string, codec Codec[T]) *TypedSortedSetKey[T] {
+	return &TypedSortedSetKey[T]{
+		Key:   NewKey(key),
+		codec: codec,
+	}
+}
+
+func (k TypedSortedSetKey[T]) ZAdd(ctx context.Context, members ...TypedZ[T]) (int64, error) {
+	return k.ZAddArgs(ctx, redis.ZAddArgs{}, members...)
+}
+
+// ZAddArgs adds members with the NX, XX, GT, LT and CH options of args, args.Members is ignored.
+func (k TypedSortedSetKey[T]) ZAddArgs(ctx context.Context, args redis.ZAddArgs, members ...TypedZ[T]) (int64, error) {
+	args.Members = make([]redis.Z, len(members))
+	for index, member := range members {
+		raw, err := k.encodeMember(ctx, member.Member)
+		if err != nil {
+			return 0, err
+		}
+
+		args.Members[index] = redis.Z{Member: raw, Score: member.Score}
+	}
+
+	return SortedSetKey{Key: k.Key}.ZAddArgs(ctx, args)
+}
+
+func (k TypedSortedSetKey[T]) ZIncrBy(ctx context.Context, member T, increment float64) (float64, error) {
+	raw, err := k.encodeMember(ctx, member)
+	if err != nil {
+		return 0, err
+	}
+
+	return SortedSetKey{Key: k.Key}.ZIncrBy(ctx, raw, increment)
+}
+
+func (k TypedSortedSetKey[T]) ZScore(ctx context.Context, member T) (float64, error) {
+	raw, err := k.encodeMember(ctx, member)
+	if err != nil {
+		return 0, err
+	}
+
+	return SortedSetKey{Key: k.Key}.ZScore(ctx, raw)
+}
+
+func (k TypedSortedSetKey[T]) ZRank(ctx context.Context, member T) (int64, error) {
+	raw, err := k.encodeMember(ctx, member)
+	if err != nil {
+		return 0, err
+	}
+
+	return SortedSetKey{Key: k.Key}.ZRank(ctx, raw)
+}
+
+func (k TypedSortedSetKey[T]) ZRevRank(ctx context.Context, member T) (int64, error) {
+	raw, err := k.encodeMember(ctx, member)
+	if err != nil {
+		return 0, err
+	}
+
+	return SortedSetKey{Key: k.Key}.ZRevRank(ctx, raw)
+}
+
+func (k TypedSortedSetKey[T]) ZRange(ctx context.Context, query ZRangeQuery) ([]T, error) {
+	rawMembers, err := SortedSetKey{Key: k.Key}.ZRange(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]T, len(rawMembers))
+	for index, rawMember := range rawMembers {
+		members[index], err = k.decodeMember(ctx, rawMember)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return members, nil
+}
+
+func (k TypedSortedSetKey[T]) ZRangeWithScores(ctx context.Context, query ZRangeQuery) ([]TypedZ[T], error) {
+	rawMembers, err := SortedSetKey{Key: k.Key}.ZRangeWithScores(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return k.decodeZ(ctx, rawMembers)
+}
+
+func (k TypedSortedSetKey[T]) ZRem(ctx context.Context, members ...T) (int64, error) {
+	rawMembers := make([]string, len(members))
+	for index, member := range members {
+		raw, err := k.encodeMember(ctx, member)
+		if err != nil {
+			return 0, err
+		}
+
+		rawMembers[index] = raw
+	}
+
+	return SortedSetKey{Key: k.Key}.ZRem(ctx, rawMembers...)
+}
+
+func (k TypedSortedSetKey[T]) ZPopMin(ctx context.Context, count int64) ([]TypedZ[T], error) {
+	rawMembers, err := SortedSetKey{Key: k.Key}.ZPopMin(ctx, count)
+	if err != nil {
+		return nil, err
+	}
+
+	return k.decodeZ(ctx, rawMembers)
+}
+
+func (k TypedSortedSetKey[T]) ZPopMax(ctx context.Context, count int64) ([]TypedZ[T], error) {
+	rawMembers, err := SortedSetKey{Key: k.Key}.ZPopMax(ctx, count)
+	if err != nil {
+		return nil, err
+	}
+
+	return k.decodeZ(ctx, rawMembers)
+}
+
+func (k TypedSortedSetKey[T]) BZPopMin(ctx context.Context, timeout time.Duration) (TypedZ[T], error) {
+	var member TypedZ[T]
+	rawMember, err := SortedSetKey{Key: k.Key}.BZPopMin(ctx, timeout)
+	if err != nil {
+		return member, err
+	}
+
+	members, err := k.decodeZ(ctx, []redis.Z{rawMember.Z})
+	if err != nil {
+		return member, err
+	}
+
+	return members[0], nil
+}
+
+func (k TypedSortedSetKey[T]) BZPopMax(ctx context.Context, timeout time.Duration) (TypedZ[T], error) {
+	var member TypedZ[T]
+	rawMember, err := SortedSetKey{Key: k.Key}.BZPopMax(ctx, timeout)
+	if err != nil {
+		return member, err
+	}
+
+	members, err := k.decodeZ(ctx, []redis.Z{rawMember.Z})
+	if err != nil {
+		return member, err
+	}
+
+	return members[0], nil
+}
+
+func (k TypedSortedSetKey[T]) ZCard(ctx context.Context) (int64, error) {
+	return SortedSetKey{Key: k.Key}.ZCard(ctx)
+}
+
+func (k TypedSortedSetKey[T]) encodeMember(ctx context.Context, member T) (string, error) {
+	buffer, err := k.codec.Encode(member)
+	if err != nil {
+		log.Warn(ctx, "encode member failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Any("member", member))
+		return "", err
+	}
+
+	return string(buffer), nil
+}
+
+func (k TypedSortedSetKey[T]) decodeMember(ctx context.Context, rawMember string) (T, error) {
+	member, err := k.codec.Decode([]byte(rawMember))
+	if err != nil {
+		log.Warn(ctx, "decode member failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("member", rawMember))
+		return member, err
+	}
+
+	return member, nil
+}
+
+func (k TypedSortedSetKey[T]) decodeZ(ctx context.Context, rawMembers []redis.Z) ([]TypedZ[T], error) {
+	members := make([]TypedZ[T], len(rawMembers))
+	for index, rawMember := range rawMembers {
+		raw, ok := rawMember.Member.(string)
+		if !ok {
+			return nil, ErrInvalidEncodedValue
+		}
+
+		member, err := k.decodeMember(ctx, raw)
+		if err != nil {
+			return nil, err
+		}
+
+		members[index] = TypedZ[T]{Member: member, Score: rawMember.Score}
+	}
+
+	return members, nil
+}
+
+type TypedSortedSetParameterKey[T any] struct {
+	*ParameterKey
+	codec Codec[T]
+}
+
+func NewTypedSortedSetParameterKey[T any](pattern string, codec Codec[T]) *TypedSortedSetParameterKey[T] {
+	return &TypedSortedSetParameterKey[T]{
+		ParameterKey: NewParameterKey(pattern),
+		codec:        codec,
+	}
+}
+
+func (k TypedSortedSetParameterKey[T]) Param(parameters ...interface{}) *TypedSortedSetKey[T] {
+	return NewTypedSortedSetKey(fmt.Sprintf(k.pattern, parameters...), k.codec)
+}
@@ -0,0 +1,44 @@
+package ro
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestTypedSortedSetKey(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewTypedSortedSetParameterKey[int64]("typed:zset:%s", IntegerCodec[int64]{}).Param("users")
+	defer key.Del(ctx)
+
+	count, err := key.ZAdd(ctx, TypedZ[int64]{Member: 100, Score: 3}, TypedZ[int64]{Member: 200, Score: 1}, TypedZ[int64]{Member: 300, Score: 2})
+	if err != nil || count != 3 {
+		t.Errorf("zadd failed, get %d, %v", count, err)
+	}
+
+	score, err := key.ZIncrBy(ctx, 200, 5)
+	if err != nil || score != 6 {
+		t.Errorf("zincrby failed, get %v, %v", score, err)
+	}
+
+	rank, err := key.ZRevRank(ctx, 200)
+	if err != nil || rank != 0 {
+		t.Errorf("zrevrank failed, get %d, %v", rank, err)
+	}
+
+	members, err := key.ZRange(ctx, ZRangeQuery{Start: 0, Stop: -1})
+	if err != nil || !reflect.DeepEqual(members, []int64{300, 100, 200}) {
+		t.Errorf("zrange failed, get %v, %v", members, err)
+	}
+
+	popped, err := key.ZPopMax(ctx, 1)
+	if err != nil || !reflect.DeepEqual(popped, []TypedZ[int64]{{Member: 200, Score: 6}}) {
+		t.Errorf("zpopmax failed, get %v, %v", popped, err)
+	}
+
+	count, err = key.ZRem(ctx, 300)
+	if err != nil || count != 1 {
+		t.Errorf("zrem failed, get %d, %v", count, err)
+	}
+}
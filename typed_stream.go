@@ -0,0 +1,131 @@
+package ro
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nzai/log"
+)
+
+type TypedXMessage[T any] struct {
+	ID     string
+	Values map[string]T
+}
+
+type TypedStreamKey[T any] struct {
+	*Key
+	codec Codec[T]
+}
+
+func NewTypedStreamKey[T any](key string, codec Codec[T]) *TypedStreamKey[T] {
+	return &TypedStreamKey[T]{
+		Key:   NewKey(key),
+		codec: codec,
+	}
+}
+
+func (s TypedStreamKey[T]) XAdd(ctx context.Context, id string, maxLen, limit int64, values map[string]T) (string, error) {
+	rawValues, err := s.encodeValues(ctx, values)
+	if err != nil {
+		return "", err
+	}
+
+	return StreamKey{Key: s.Key}.XAdd(ctx, id, maxLen, limit, rawValues)
+}
+
+func (s TypedStreamKey[T]) XAddBatch(ctx context.Context, maxLen, limit int64, messages []map[string]T) ([]string, error) {
+	rawMessages := make([]map[string]interface{}, len(messages))
+	for index, values := range messages {
+		rawValues, err := s.encodeValues(ctx, values)
+		if err != nil {
+			return nil, err
+		}
+
+		rawMessages[index] = rawValues
+	}
+
+	return StreamKey{Key: s.Key}.XAddBatch(ctx, maxLen, limit, rawMessages)
+}
+
+func (s TypedStreamKey[T]) encodeValues(ctx context.Context, values map[string]T) (map[string]interface{}, error) {
+	rawValues := make(map[string]interface{}, len(values))
+	for field, value := range values {
+		buffer, err := s.codec.Encode(value)
+		if err != nil {
+			log.Warn(ctx, "encode message value failed",
+				log.Err(err),
+				log.String("key", s.key),
+				log.String("field", field),
+				log.Any("value", value))
+			return nil, err
+		}
+
+		rawValues[field] = string(buffer)
+	}
+
+	return rawValues, nil
+}
+
+func (s TypedStreamKey[T]) XAddToEnd(ctx context.Context, values map[string]T) (string, error) {
+	return s.XAdd(ctx, "*", 0, 0, values)
+}
+
+func (s TypedStreamKey[T]) XGroupRead(ctx context.Context, group, consumer string, count int64, block time.Duration, noAck bool) ([]TypedXMessage[T], error) {
+	messages, err := StreamKey{Key: s.Key}.XGroupRead(ctx, group, consumer, count, block, noAck)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]TypedXMessage[T], len(messages))
+	for index, message := range messages {
+		values := make(map[string]T, len(message.Values))
+		for field, rawValue := range message.Values {
+			raw, ok := rawValue.(string)
+			if !ok {
+				log.Warn(ctx, "invalid message value",
+					log.String("key", s.key),
+					log.String("id", message.ID),
+					log.String("field", field),
+					log.Any("value", rawValue))
+				return nil, ErrInvalidEncodedValue
+			}
+
+			value, err := s.codec.Decode([]byte(raw))
+			if err != nil {
+				log.Warn(ctx, "decode message value failed",
+					log.Err(err),
+					log.String("key", s.key),
+					log.String("id", message.ID),
+					log.String("field", field),
+					log.String("value", raw))
+				return nil, err
+			}
+
+			values[field] = value
+		}
+
+		result[index] = TypedXMessage[T]{
+			ID:     message.ID,
+			Values: values,
+		}
+	}
+
+	return result, nil
+}
+
+type TypedStreamParameterKey[T any] struct {
+	*ParameterKey
+	codec Codec[T]
+}
+
+func NewTypedStreamParameterKey[T any](pattern string, codec Codec[T]) *TypedStreamParameterKey[T] {
+	return &TypedStreamParameterKey[T]{
+		ParameterKey: NewParameterKey(pattern),
+		codec:        codec,
+	}
+}
+
+func (k TypedStreamParameterKey[T]) Param(parameters ...interface{}) *TypedStreamKey[T] {
+	return NewTypedStreamKey(fmt.Sprintf(k.pattern, parameters...), k.codec)
+}
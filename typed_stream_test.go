@@ -0,0 +1,33 @@
+package ro
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTypedStreamKey_XGroupRead(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewTypedStreamParameterKey[int64]("typed:stream:%d", IntegerCodec[int64]{}).Param(1)
+	defer key.Del(ctx)
+
+	err := StreamKey{Key: key.Key}.XGroupCreateFromBegining(ctx, "group1")
+	if err != nil {
+		t.Fatalf("failed to create group due to %v", err)
+	}
+
+	_, err = key.XAddToEnd(ctx, map[string]int64{"a": 1, "b": 2})
+	if err != nil {
+		t.Fatalf("xadd failed due to %v", err)
+	}
+
+	messages, err := key.XGroupRead(ctx, "group1", "consumer1", 1, time.Millisecond*100, true)
+	if err != nil {
+		t.Fatalf("failed to read from group due to %v", err)
+	}
+
+	if len(messages) != 1 || messages[0].Values["a"] != 1 || messages[0].Values["b"] != 2 {
+		t.Errorf("get unexpect messages %+v", messages)
+	}
+}
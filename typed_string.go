@@ -0,0 +1,94 @@
+package ro
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nzai/log"
+)
+
+type TypedStringKey[T any] struct {
+	*Key
+	codec Codec[T]
+}
+
+func NewTypedStringKey[T any](key string, codec Codec[T]) *TypedStringKey[T] {
+	return &TypedStringKey[T]{
+		Key:   NewKey(key),
+		codec: codec,
+	}
+}
+
+func (k TypedStringKey[T]) Get(ctx context.Context) (T, error) {
+	var value T
+	raw, err := StringKey{Key: k.Key}.Get(ctx)
+	if err != nil {
+		return value, err
+	}
+
+	value, err = k.codec.Decode([]byte(raw))
+	if err != nil {
+		log.Warn(ctx, "decode value failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.String("value", raw))
+		return value, err
+	}
+
+	return value, nil
+}
+
+func (k TypedStringKey[T]) GetDefault(ctx context.Context, defaultValue T) T {
+	value, err := k.Get(ctx)
+	if err != nil {
+		log.Debug(ctx, "get value failed, use default value instead",
+			log.Err(err), log.String("key", k.key),
+			log.Any("defaultValue", defaultValue))
+		return defaultValue
+	}
+
+	return value
+}
+
+func (k TypedStringKey[T]) Set(ctx context.Context, value T, expiration time.Duration, tags ...string) error {
+	buffer, err := k.codec.Encode(value)
+	if err != nil {
+		log.Warn(ctx, "encode value failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Any("value", value))
+		return err
+	}
+
+	return StringKey{Key: k.Key}.SetWithTags(ctx, string(buffer), expiration, tags...)
+}
+
+func (k TypedStringKey[T]) SetNX(ctx context.Context, value T, expiration time.Duration) (bool, error) {
+	buffer, err := k.codec.Encode(value)
+	if err != nil {
+		log.Warn(ctx, "encode value failed",
+			log.Err(err),
+			log.String("key", k.key),
+			log.Any("value", value))
+		return false, err
+	}
+
+	return StringKey{Key: k.Key}.SetNX(ctx, string(buffer), expiration)
+}
+
+type TypedStringParameterKey[T any] struct {
+	*ParameterKey
+	codec Codec[T]
+}
+
+func NewTypedStringParameterKey[T any](pattern string, codec Codec[T]) *TypedStringParameterKey[T] {
+	return &TypedStringParameterKey[T]{
+		ParameterKey: NewParameterKey(pattern),
+		codec:        codec,
+	}
+}
+
+func (k TypedStringParameterKey[T]) Param(parameters ...interface{}) *TypedStringKey[T] {
+	return NewTypedStringKey(fmt.Sprintf(k.pattern, parameters...), k.codec)
+}
@@ -0,0 +1,37 @@
+package ro
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTypedStringKey_SetAndGet(t *testing.T) {
+	ctx := context.Background()
+
+	key := NewTypedStringParameterKey[*testStruct]("typed:string:%d", JSONCodec[*testStruct]{}).Param(1)
+	defer key.Del(ctx)
+
+	obj := &testStruct{AAA: "aaa", BBB: 111}
+	err := key.Set(ctx, obj, time.Minute)
+	if err != nil {
+		t.Errorf("set typed value failed due to %v", err)
+	}
+
+	get, err := key.Get(ctx)
+	if err != nil {
+		t.Errorf("get typed value failed due to %v", err)
+	}
+
+	if !reflect.DeepEqual(get, obj) {
+		t.Errorf("get typed value not equal, want %v, get %v", obj, get)
+	}
+
+	counter := NewTypedStringKey[int64]("typed:counter", IntegerCodec[int64]{})
+	defer counter.Del(ctx)
+
+	if got := counter.GetDefault(ctx, 7); got != 7 {
+		t.Errorf("get default value failed, want 7, get %d", got)
+	}
+}